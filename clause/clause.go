@@ -0,0 +1,65 @@
+package clause
+
+import "strings"
+
+// Expr is a raw SQL expression with bind values, e.g.
+// Expr{SQL: "stock + ?", Vars: []any{10}}.
+type Expr struct {
+	SQL  string
+	Vars []any
+}
+
+// Expand inlines the Expr arguments of a condition: the ? bound to an Expr
+// is replaced by its SQL and the Expr by its Vars. Question marks inside
+// quoted strings and identifiers are left untouched.
+func Expand(sql string, args []any) (string, []any) {
+	hasExpr := false
+	for _, a := range args {
+		if _, ok := a.(Expr); ok {
+			hasExpr = true
+			break
+		}
+	}
+	if !hasExpr {
+		return sql, args
+	}
+
+	var sb strings.Builder
+	out := make([]any, 0, len(args))
+	n := 0
+	var quote rune
+	for _, r := range sql {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '\'' || r == '"' || r == '`':
+			quote = r
+		case r == '?' && n < len(args):
+			arg := args[n]
+			n++
+			if e, ok := arg.(Expr); ok {
+				sb.WriteString(e.SQL)
+				out = append(out, e.Vars...)
+				continue
+			}
+			out = append(out, arg)
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String(), append(out, args[n:]...)
+}
+
+// OnConflict controls how an INSERT behaves when it hits a unique or primary key.
+// Columns and values may be given as Go field names or column names.
+//
+// MySQL renders it as ON DUPLICATE KEY UPDATE (Columns is ignored there, any
+// unique key triggers it); PostgreSQL and SQLite render ON CONFLICT (...).
+type OnConflict struct {
+	Columns   []string       // conflict target, defaults to the ID column
+	DoNothing bool           // keep the existing row
+	UpdateAll bool           // overwrite every inserted column except the conflict target
+	DoUpdates []string       // overwrite only these columns with the inserted values
+	Set       map[string]any // assign explicit values or Exprs
+}
@@ -0,0 +1,27 @@
+package clause_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/adipras/torm/clause"
+)
+
+func TestExpand(t *testing.T) {
+	sql, args := clause.Expand(
+		"stock > ? AND note <> '?' AND id = ?",
+		[]any{clause.Expr{SQL: "reserved + ?", Vars: []any{2}}, 7},
+	)
+
+	if want := "stock > reserved + ? AND note <> '?' AND id = ?"; sql != want {
+		t.Errorf("Expand sql = %s, want %s", sql, want)
+	}
+	if want := []any{2, 7}; !reflect.DeepEqual(args, want) {
+		t.Errorf("Expand args = %v, want %v", args, want)
+	}
+
+	plain := []any{1, "x"}
+	if sql, args := clause.Expand("a = ? AND b = ?", plain); sql != "a = ? AND b = ?" || !reflect.DeepEqual(args, plain) {
+		t.Errorf("Expand without Expr changed %s %v", sql, args)
+	}
+}
@@ -1,21 +1,94 @@
 package db
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
+	"time"
+
+	"github.com/adipras/torm/dialect"
+	"github.com/adipras/torm/model"
 )
 
+// DefaultTimeout is the query timeout applied when the caller's context has no deadline.
+const DefaultTimeout = 5 * time.Second
+
+// Conn is the query interface shared by *sql.DB and *sql.Tx.
+type Conn interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
 type DB struct {
 	SQL *sql.DB
+	Tx  *sql.Tx // non-nil while inside a transaction
+
+	// Dialect renders placeholders, identifiers and LIMIT clauses for the driver.
+	Dialect dialect.Dialect
+
+	// Timeout bounds every query whose context has no deadline of its own.
+	// Zero disables the timeout so only the caller's context applies.
+	Timeout time.Duration
+
+	// Naming derives table and column names for models. Nil uses the defaults.
+	Naming *model.NamingStrategy
+
+	// NowFunc returns the time stored in automatic CreatedAt/UpdatedAt
+	// timestamps. Nil uses time.Now.
+	NowFunc func() time.Time
+
+	// Unscoped disables soft-delete handling: queries see soft-deleted rows
+	// and deletes remove rows permanently.
+	Unscoped bool
+
+	// RequireAffected makes updates and deletes that affect no rows
+	// return sql.ErrNoRows, e.g. when updating a row by ID.
+	RequireAffected bool
+
+	// AllowGlobalUpdate permits updates and deletes without a WHERE
+	// condition, which otherwise fail with ErrMissingWhereClause.
+	AllowGlobalUpdate bool
+
+	depth     int    // savepoint nesting level, 0 for the outermost transaction
+	savepoint string // savepoint name when depth > 0
 }
 
-// New creates a new DB wrapper.
+// New creates a new DB wrapper, selecting the SQL dialect from the driver name.
 func New(driver, dsn string) (*DB, error) {
 	sqlDB, err := sql.Open(driver, dsn)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
-	return &DB{SQL: sqlDB}, nil
+	return &DB{SQL: sqlDB, Dialect: dialect.For(driver), Timeout: DefaultTimeout}, nil
+}
+
+// WithTimeout derives the context used to run a query.
+// A deadline already set on ctx always wins over the configured Timeout.
+func (db *DB) WithTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if _, ok := ctx.Deadline(); ok || db.Timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, db.Timeout)
+}
+
+// Now returns the current time according to NowFunc.
+func (db *DB) Now() time.Time {
+	if db.NowFunc != nil {
+		return db.NowFunc()
+	}
+	return time.Now()
+}
+
+// Conn returns the active transaction if there is one, otherwise the connection pool.
+func (db *DB) Conn() Conn {
+	if db.Tx != nil {
+		return db.Tx
+	}
+	return db.SQL
 }
 
 // Ping verifies the database connection.
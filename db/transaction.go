@@ -0,0 +1,56 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+var ErrNotInTransaction = errors.New("not in a transaction")
+
+// Begin starts a transaction and returns a DB bound to it.
+// When called on a DB that is already inside a transaction,
+// a SAVEPOINT is created instead so transactions can be nested.
+func (db *DB) Begin(ctx context.Context) (*DB, error) {
+	child := *db
+
+	if db.Tx == nil {
+		tx, err := db.SQL.BeginTx(ctx, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to begin transaction: %w", err)
+		}
+		child.Tx = tx
+		return &child, nil
+	}
+
+	child.depth = db.depth + 1
+	child.savepoint = fmt.Sprintf("sp_%d", child.depth)
+	if _, err := db.Tx.ExecContext(ctx, "SAVEPOINT "+child.savepoint); err != nil {
+		return nil, fmt.Errorf("failed to create savepoint: %w", err)
+	}
+	return &child, nil
+}
+
+// Commit commits the transaction, or releases the savepoint for a nested one.
+func (db *DB) Commit() error {
+	if db.Tx == nil {
+		return ErrNotInTransaction
+	}
+	if db.savepoint != "" {
+		_, err := db.Tx.ExecContext(context.Background(), "RELEASE SAVEPOINT "+db.savepoint)
+		return err
+	}
+	return db.Tx.Commit()
+}
+
+// Rollback aborts the transaction, or rolls back to the savepoint for a nested one.
+func (db *DB) Rollback() error {
+	if db.Tx == nil {
+		return ErrNotInTransaction
+	}
+	if db.savepoint != "" {
+		_, err := db.Tx.ExecContext(context.Background(), "ROLLBACK TO SAVEPOINT "+db.savepoint)
+		return err
+	}
+	return db.Tx.Rollback()
+}
@@ -0,0 +1,120 @@
+package dialect
+
+import (
+	"strings"
+)
+
+// InsertIDStrategy describes how generated IDs are read back after an INSERT.
+type InsertIDStrategy int
+
+const (
+	// LastInsertID reads the ID from sql.Result.LastInsertId.
+	// For a multi-row INSERT it is the ID of the first row (MySQL).
+	LastInsertID InsertIDStrategy = iota
+	// Returning appends a RETURNING clause and scans the ID from the result row.
+	Returning
+	// LastInsertRowID reads the ID from sql.Result.LastInsertId.
+	// For a multi-row INSERT it is the ID of the last row (SQLite).
+	LastInsertRowID
+)
+
+// Conflict is the input for rendering an upsert clause.
+// All column names are already quoted.
+type Conflict struct {
+	Target  []string // conflict target columns
+	Columns []string // inserted columns
+	Set     []string // rendered "col = expr" assignments; empty means do nothing
+}
+
+// Dialect hides the SQL differences between database engines.
+type Dialect interface {
+	// Name returns the dialect name (e.g. "mysql").
+	Name() string
+	// Placeholder returns the bind variable for the n-th argument, starting at 1.
+	Placeholder(n int) string
+	// Quote quotes a table or column identifier.
+	Quote(ident string) string
+	// LimitOffset renders the LIMIT/OFFSET clause. A negative value means unset.
+	LimitOffset(limit, offset int) string
+	// InsertID reports how generated IDs are read back after an INSERT.
+	InsertID() InsertIDStrategy
+	// MaxPlaceholders is the most bind variables a single statement may use.
+	MaxPlaceholders() int
+	// Upsert renders the clause appended to an INSERT to handle conflicts.
+	Upsert(c Conflict) string
+	// Excluded references the value an INSERT proposed for col (quoted).
+	Excluded(col string) string
+	// RowValueIn reports whether "(a, b) IN ((?, ?), ...)" is supported.
+	RowValueIn() bool
+	// WriteLimit reports whether UPDATE and DELETE accept ORDER BY and LIMIT.
+	WriteLimit() bool
+	// DefaultValues renders the part of an INSERT after the table name that
+	// inserts one row of column defaults, for a row with no columns to set.
+	DefaultValues() string
+}
+
+// For returns the dialect for the given database/sql driver name.
+// Unknown drivers fall back to MySQL.
+func For(driver string) Dialect {
+	switch strings.ToLower(driver) {
+	case "postgres", "pgx", "pgx/v5", "cloudsqlpostgres":
+		return Postgres{}
+	case "sqlite", "sqlite3", "libsql":
+		return SQLite{}
+	default:
+		return MySQL{}
+	}
+}
+
+// Rebind rewrites the ? placeholders in query to the dialect's bind variables.
+// Question marks inside quoted strings and identifiers are left untouched.
+func Rebind(d Dialect, query string) string {
+	if d.Placeholder(1) == "?" || !strings.Contains(query, "?") {
+		return query
+	}
+
+	var sb strings.Builder
+	n := 0
+	var quote rune
+	for _, r := range query {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '\'' || r == '"' || r == '`':
+			quote = r
+		case r == '?':
+			n++
+			sb.WriteString(d.Placeholder(n))
+			continue
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
+
+// onConflict renders the PostgreSQL/SQLite ON CONFLICT clause.
+func onConflict(c Conflict) string {
+	s := "ON CONFLICT"
+	if len(c.Target) > 0 {
+		s += " (" + strings.Join(c.Target, ", ") + ")"
+	}
+	if len(c.Set) == 0 {
+		return s + " DO NOTHING"
+	}
+	return s + " DO UPDATE SET " + strings.Join(c.Set, ", ")
+}
+
+// quoteWith quotes each dot-separated part of ident with q,
+// leaving "*" and already quoted parts as they are.
+func quoteWith(ident string, q string) string {
+	parts := strings.Split(ident, ".")
+	for i, p := range parts {
+		if p == "*" || (len(p) >= 2 && strings.HasPrefix(p, q) && strings.HasSuffix(p, q)) {
+			continue
+		}
+		parts[i] = q + strings.ReplaceAll(p, q, q+q) + q
+	}
+	return strings.Join(parts, ".")
+}
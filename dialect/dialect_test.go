@@ -0,0 +1,74 @@
+package dialect_test
+
+import (
+	"testing"
+
+	"github.com/adipras/torm/dialect"
+)
+
+func TestFor(t *testing.T) {
+	cases := map[string]string{
+		"mysql":    "mysql",
+		"postgres": "postgres",
+		"pgx":      "postgres",
+		"sqlite3":  "sqlite",
+		"unknown":  "mysql",
+	}
+	for driver, want := range cases {
+		if got := dialect.For(driver).Name(); got != want {
+			t.Errorf("For(%q) = %s, want %s", driver, got, want)
+		}
+	}
+}
+
+func TestRebind(t *testing.T) {
+	q := "SELECT * FROM users WHERE name = ? AND note <> '?' AND age > ?"
+
+	if got := dialect.Rebind(dialect.MySQL{}, q); got != q {
+		t.Errorf("MySQL rebind changed query: %s", got)
+	}
+
+	want := "SELECT * FROM users WHERE name = $1 AND note <> '?' AND age > $2"
+	if got := dialect.Rebind(dialect.Postgres{}, q); got != want {
+		t.Errorf("Postgres rebind = %s, want %s", got, want)
+	}
+}
+
+func TestQuote(t *testing.T) {
+	cases := []struct {
+		d     dialect.Dialect
+		ident string
+		want  string
+	}{
+		{dialect.MySQL{}, "users", "`users`"},
+		{dialect.MySQL{}, "users.*", "`users`.*"},
+		{dialect.Postgres{}, "public.users", `"public"."users"`},
+		{dialect.SQLite{}, `"users"`, `"users"`},
+		{dialect.Postgres{}, `we"ird`, `"we""ird"`},
+	}
+	for _, c := range cases {
+		if got := c.d.Quote(c.ident); got != c.want {
+			t.Errorf("%s.Quote(%q) = %s, want %s", c.d.Name(), c.ident, got, c.want)
+		}
+	}
+}
+
+func TestLimitOffset(t *testing.T) {
+	cases := []struct {
+		d             dialect.Dialect
+		limit, offset int
+		want          string
+	}{
+		{dialect.MySQL{}, 10, -1, "LIMIT 10"},
+		{dialect.MySQL{}, 10, 20, "LIMIT 10 OFFSET 20"},
+		{dialect.MySQL{}, -1, 20, "LIMIT 18446744073709551615 OFFSET 20"},
+		{dialect.Postgres{}, -1, 20, "OFFSET 20"},
+		{dialect.SQLite{}, -1, 20, "LIMIT -1 OFFSET 20"},
+		{dialect.SQLite{}, -1, -1, ""},
+	}
+	for _, c := range cases {
+		if got := c.d.LimitOffset(c.limit, c.offset); got != c.want {
+			t.Errorf("%s.LimitOffset(%d, %d) = %q, want %q", c.d.Name(), c.limit, c.offset, got, c.want)
+		}
+	}
+}
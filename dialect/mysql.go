@@ -0,0 +1,59 @@
+package dialect
+
+import (
+	"strconv"
+	"strings"
+)
+
+// MySQL implements Dialect for MySQL and MariaDB.
+type MySQL struct{}
+
+func (MySQL) Name() string { return "mysql" }
+
+func (MySQL) Placeholder(int) string { return "?" }
+
+func (MySQL) Quote(ident string) string { return quoteWith(ident, "`") }
+
+func (MySQL) LimitOffset(limit, offset int) string {
+	if offset >= 0 && limit < 0 {
+		// MySQL has no OFFSET without LIMIT; use the documented max value
+		return "LIMIT 18446744073709551615 OFFSET " + strconv.Itoa(offset)
+	}
+	return limitOffset(limit, offset)
+}
+
+func (MySQL) InsertID() InsertIDStrategy { return LastInsertID }
+
+func (MySQL) MaxPlaceholders() int { return 65535 }
+
+func (MySQL) Upsert(c Conflict) string {
+	set := c.Set
+	if len(set) == 0 && len(c.Columns) > 0 {
+		// No DO NOTHING in MySQL; a no-op assignment keeps the existing row
+		set = []string{c.Columns[0] + " = " + c.Columns[0]}
+	}
+	return "ON DUPLICATE KEY UPDATE " + strings.Join(set, ", ")
+}
+
+func (MySQL) Excluded(col string) string { return "VALUES(" + col + ")" }
+
+func (MySQL) RowValueIn() bool { return true }
+
+func (MySQL) WriteLimit() bool { return true }
+
+func (MySQL) DefaultValues() string { return "() VALUES ()" }
+
+// limitOffset renders the standard "LIMIT n OFFSET m" form.
+func limitOffset(limit, offset int) string {
+	s := ""
+	if limit >= 0 {
+		s = "LIMIT " + strconv.Itoa(limit)
+	}
+	if offset >= 0 {
+		if s != "" {
+			s += " "
+		}
+		s += "OFFSET " + strconv.Itoa(offset)
+	}
+	return s
+}
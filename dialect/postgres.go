@@ -0,0 +1,28 @@
+package dialect
+
+import "strconv"
+
+// Postgres implements Dialect for PostgreSQL.
+type Postgres struct{}
+
+func (Postgres) Name() string { return "postgres" }
+
+func (Postgres) Placeholder(n int) string { return "$" + strconv.Itoa(n) }
+
+func (Postgres) Quote(ident string) string { return quoteWith(ident, `"`) }
+
+func (Postgres) LimitOffset(limit, offset int) string { return limitOffset(limit, offset) }
+
+func (Postgres) InsertID() InsertIDStrategy { return Returning }
+
+func (Postgres) MaxPlaceholders() int { return 65535 }
+
+func (Postgres) Upsert(c Conflict) string { return onConflict(c) }
+
+func (Postgres) Excluded(col string) string { return "EXCLUDED." + col }
+
+func (Postgres) RowValueIn() bool { return true }
+
+func (Postgres) WriteLimit() bool { return false }
+
+func (Postgres) DefaultValues() string { return "DEFAULT VALUES" }
@@ -0,0 +1,37 @@
+package dialect
+
+import "strconv"
+
+// SQLite implements Dialect for SQLite.
+type SQLite struct{}
+
+func (SQLite) Name() string { return "sqlite" }
+
+func (SQLite) Placeholder(int) string { return "?" }
+
+func (SQLite) Quote(ident string) string { return quoteWith(ident, `"`) }
+
+func (SQLite) LimitOffset(limit, offset int) string {
+	if offset >= 0 && limit < 0 {
+		// SQLite requires LIMIT before OFFSET; -1 means no limit
+		return "LIMIT -1 OFFSET " + strconv.Itoa(offset)
+	}
+	return limitOffset(limit, offset)
+}
+
+func (SQLite) InsertID() InsertIDStrategy { return LastInsertRowID }
+
+// SQLITE_MAX_VARIABLE_NUMBER before SQLite 3.32
+func (SQLite) MaxPlaceholders() int { return 999 }
+
+func (SQLite) Upsert(c Conflict) string { return onConflict(c) }
+
+func (SQLite) Excluded(col string) string { return "EXCLUDED." + col }
+
+// SQLite only accepts a subquery on the right-hand side of a row-value IN
+func (SQLite) RowValueIn() bool { return false }
+
+// Only builds with SQLITE_ENABLE_UPDATE_DELETE_LIMIT accept it
+func (SQLite) WriteLimit() bool { return false }
+
+func (SQLite) DefaultValues() string { return "DEFAULT VALUES" }
@@ -3,171 +3,863 @@ package executor
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"reflect"
+	"sort"
 	"strings"
 	"time"
 
+	"github.com/adipras/torm/clause"
+	"github.com/adipras/torm/db"
+	"github.com/adipras/torm/dialect"
 	"github.com/adipras/torm/model"
 	"github.com/adipras/torm/utils"
 )
 
-// Create inserts a single record into the database
-func Create(db *sql.DB, modelRef any, data any) error {
-	schema, err := model.ExtractSchema(modelRef)
+// ErrReturningNotSupported is returned by the *Returning functions
+// when the dialect has no RETURNING clause.
+var ErrReturningNotSupported = errors.New("dialect does not support RETURNING")
+
+// ErrStaleObject is returned when a version-guarded update matches no row
+// because the row was changed or deleted since it was read.
+var ErrStaleObject = errors.New("stale object: row was modified concurrently")
+
+// ErrNoSoftDelete is returned when restoring rows of a model without a DeletedAt field.
+var ErrNoSoftDelete = errors.New("model has no soft-delete field")
+
+// ErrUnknownColumn is returned when an update names a column the model does not have.
+var ErrUnknownColumn = errors.New("unknown column")
+
+// ErrMissingWhereClause is returned for an update or delete without a WHERE
+// condition, which would affect every row, unless global updates are allowed.
+var ErrMissingWhereClause = errors.New("missing WHERE clause")
+
+// Create inserts a single record, or a slice of records, into the database
+func Create(d *db.DB, modelRef any, data any) error {
+	return CreateContext(d, context.Background(), modelRef, data)
+}
+
+// CreateContext inserts a single record, or a slice of records, into the database using the provided context
+func CreateContext(d *db.DB, ctx context.Context, modelRef any, data any) error {
+	return CreateInBatchesContext(d, ctx, modelRef, data, 0)
+}
+
+// CreateInBatchesContext inserts data, a struct or a slice of structs.
+// Slices are written with multi-row INSERTs of at most batchSize rows;
+// a batchSize <= 0, or one that would exceed the dialect's placeholder
+// limit, is reduced to the largest batch the dialect allows.
+// When more than one statement is needed they run in a single transaction.
+// Generated IDs are set on each element.
+func CreateInBatchesContext(d *db.DB, ctx context.Context, modelRef any, data any, batchSize int) error {
+	return create(d, ctx, modelRef, data, batchSize, nil)
+}
+
+// UpsertContext inserts data like CreateContext, resolving unique key
+// conflicts as described by conflict.
+func UpsertContext(d *db.DB, ctx context.Context, modelRef any, data any, conflict clause.OnConflict) error {
+	return create(d, ctx, modelRef, data, 0, &conflict)
+}
+
+// UpsertInBatchesContext upserts data like UpsertContext, with at most
+// batchSize rows per statement as in CreateInBatchesContext.
+func UpsertInBatchesContext(d *db.DB, ctx context.Context, modelRef any, data any, batchSize int, conflict clause.OnConflict) error {
+	return create(d, ctx, modelRef, data, batchSize, &conflict)
+}
+
+// create inserts data in batches, adding an upsert clause when conflict is set.
+func create(d *db.DB, ctx context.Context, modelRef any, data any, batchSize int, conflict *clause.OnConflict) error {
+	schema, err := parseSchema(d, modelRef)
 	if err != nil {
 		return err
 	}
 
-	vmap, err := model.ExtractValues(data)
+	records, err := structValues(data)
 	if err != nil {
 		return err
 	}
+	if len(records) == 0 {
+		return nil
+	}
 
-	fieldNames := []string{}
-	placeholders := []string{}
-	values := []any{}
+	vmaps := make([]map[string]any, len(records))
+	for i, rec := range records {
+		if vmaps[i], err = model.ExtractValues(rec.Interface()); err != nil {
+			return err
+		}
+	}
+
+	// Fill unset CreatedAt/UpdatedAt and versions, on the records too when they are addressable.
+	// An upsert may update the row, so it always refreshes UpdatedAt.
+	now := d.Now()
+	for i, rec := range records {
+		for _, f := range schema.Fields {
+			unset := (f.AutoCreateTime || f.AutoUpdateTime) && isZero(vmaps[i][f.Name])
+			if unset || (f.AutoUpdateTime && conflict != nil) {
+				ts := timestamp(f, now)
+				vmaps[i][f.Name] = ts.Interface()
+				if fv := fieldTarget(f, rec); fv.CanSet() {
+					fv.Set(ts)
+				}
+			}
+			// Versions start at 1
+			if f.Version && isZero(vmaps[i][f.Name]) {
+				v := reflect.ValueOf(1).Convert(f.GoType)
+				vmaps[i][f.Name] = v.Interface()
+				if fv := fieldTarget(f, rec); fv.CanSet() {
+					fv.Set(v)
+				}
+			}
+		}
+	}
 
+	fields := insertFields(schema, vmaps)
+
+	var upsert *upsertClause
+	if conflict != nil {
+		if upsert, err = buildUpsert(d, schema, fields, *conflict); err != nil {
+			return err
+		}
+	}
+
+	maxRows := d.Dialect.MaxPlaceholders()
+	if upsert != nil {
+		maxRows -= len(upsert.vars)
+	}
+	if len(fields) > 0 {
+		maxRows /= len(fields)
+	} else {
+		// DEFAULT VALUES inserts a single row
+		maxRows = 1
+	}
+	if batchSize <= 0 || batchSize > maxRows {
+		batchSize = maxRows
+	}
+
+	if len(records) <= batchSize {
+		return insertBatch(d, ctx, schema, fields, records, vmaps, upsert)
+	}
+
+	// Several statements are needed; keep them atomic
+	tx, err := d.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	for start := 0; start < len(records); start += batchSize {
+		end := min(start+batchSize, len(records))
+		if err := insertBatch(tx, ctx, schema, fields, records[start:end], vmaps[start:end], upsert); err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// structValues returns the struct values held by data, a struct, a pointer
+// to struct, or a slice/array of either.
+func structValues(data any) ([]reflect.Value, error) {
+	rv := reflect.ValueOf(data)
+	if rv.Kind() == reflect.Ptr {
+		rv = rv.Elem()
+	}
+
+	switch rv.Kind() {
+	case reflect.Struct:
+		return []reflect.Value{rv}, nil
+	case reflect.Slice, reflect.Array:
+		records := make([]reflect.Value, rv.Len())
+		for i := range records {
+			elem := rv.Index(i)
+			if elem.Kind() == reflect.Ptr {
+				elem = elem.Elem()
+			}
+			if elem.Kind() != reflect.Struct {
+				return nil, fmt.Errorf("data must be a struct or a slice of structs")
+			}
+			records[i] = elem
+		}
+		return records, nil
+	default:
+		return nil, fmt.Errorf("data must be a struct or a slice of structs")
+	}
+}
+
+// insertFields returns the fields to insert. A zero primary key and zero
+// database defaults are left out so the database generates them, unless
+// some record in the batch sets a value. Composite keys are always inserted;
+// read-only columns never are.
+func insertFields(schema *model.Schema, vmaps []map[string]any) []model.Field {
+	fields := []model.Field{}
 	for _, f := range schema.Fields {
-		if val, ok := vmap[f.Name]; ok {
-			fieldNames = append(fieldNames, f.Column())
-			placeholders = append(placeholders, "?")
-			values = append(values, val)
+		if _, ok := vmaps[0][f.Name]; !ok || f.ReadOnly {
+			continue
 		}
+		if (f.PK && len(schema.PrimaryFields) == 1) || f.HasDefault {
+			allZero := true
+			for _, vmap := range vmaps {
+				if !isZero(vmap[f.Name]) {
+					allZero = false
+					break
+				}
+			}
+			if allZero {
+				continue
+			}
+		}
+		fields = append(fields, f)
 	}
+	return fields
+}
 
-	query := fmt.Sprintf(
-		"INSERT INTO %s (%s) VALUES (%s)",
-		schema.Table(),
-		strings.Join(fieldNames, ", "),
-		strings.Join(placeholders, ", "),
-	)
+// upsertClause is a rendered conflict clause and its bind values.
+type upsertClause struct {
+	sql       string
+	vars      []any
+	doNothing bool
+}
+
+// buildUpsert renders conflict for the dialect, validating column names against schema.
+func buildUpsert(d *db.DB, schema *model.Schema, fields []model.Field, conflict clause.OnConflict) (*upsertClause, error) {
+	column := func(name string) (string, error) {
+		f, ok := schema.LookUpField(name)
+		if !ok {
+			return "", fmt.Errorf("%w %q for table %s", ErrUnknownColumn, name, schema.Table())
+		}
+		return f.Column(), nil
+	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	c := dialect.Conflict{}
+	target := map[string]bool{}
+	for _, name := range conflict.Columns {
+		col, err := column(name)
+		if err != nil {
+			return nil, err
+		}
+		target[col] = true
+		c.Target = append(c.Target, d.Dialect.Quote(col))
+	}
+	pkTarget := []string{}
+	if len(c.Target) == 0 {
+		for _, f := range schema.PrimaryFields {
+			target[f.Column()] = true
+			pkTarget = append(pkTarget, d.Dialect.Quote(f.Column()))
+		}
+	}
+
+	for _, f := range fields {
+		c.Columns = append(c.Columns, d.Dialect.Quote(f.Column()))
+	}
+
+	up := &upsertClause{}
+	if !conflict.DoNothing {
+		updates := []string{}
+		if conflict.UpdateAll {
+			for _, f := range fields {
+				// Keep the creation time and deletion state of the existing row
+				if !target[f.Column()] && !f.AutoCreateTime && !f.Version && !f.SoftDelete {
+					updates = append(updates, f.Column())
+				}
+			}
+		}
+		for _, name := range conflict.DoUpdates {
+			col, err := column(name)
+			if err != nil {
+				return nil, err
+			}
+			updates = append(updates, col)
+		}
+		for _, col := range updates {
+			q := d.Dialect.Quote(col)
+			c.Set = append(c.Set, q+" = "+d.Dialect.Excluded(q))
+		}
+		if f, ok := schema.VersionField(); ok && conflict.UpdateAll {
+			q := d.Dialect.Quote(f.Column())
+			c.Set = append(c.Set, q+" = "+d.Dialect.Quote(schema.Table()+"."+f.Column())+" + 1")
+		}
+
+		// Sorted so the statement text is stable
+		keys := make([]string, 0, len(conflict.Set))
+		for k := range conflict.Set {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			col, err := column(k)
+			if err != nil {
+				return nil, err
+			}
+			switch v := conflict.Set[k].(type) {
+			case clause.Expr:
+				c.Set = append(c.Set, d.Dialect.Quote(col)+" = "+v.SQL)
+				up.vars = append(up.vars, v.Vars...)
+			default:
+				c.Set = append(c.Set, d.Dialect.Quote(col)+" = ?")
+				up.vars = append(up.vars, v)
+			}
+		}
+	}
+
+	// DO UPDATE needs a target; DO NOTHING without one covers every unique key
+	if len(c.Target) == 0 && len(c.Set) > 0 {
+		c.Target = pkTarget
+	}
+
+	up.doNothing = len(c.Set) == 0
+	up.sql = d.Dialect.Upsert(c)
+	return up, nil
+}
+
+// insertBatch runs one INSERT for records and reads generated IDs and
+// database defaults back into them.
+func insertBatch(d *db.DB, ctx context.Context, schema *model.Schema, fields []model.Field, records []reflect.Value, vmaps []map[string]any, upsert *upsertClause) error {
+	query, values, returning := buildInsert(d, schema, fields, vmaps, upsert)
+
+	ctx, cancel := d.WithTimeout(ctx)
 	defer cancel()
 
-	res, err := db.ExecContext(ctx, query, values...)
+	// Read the IDs and database defaults back in the same round trip
+	if len(returning) > 0 {
+		rows, err := d.Conn().QueryContext(ctx, query, values...)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for i := 0; rows.Next() && i < len(records); i++ {
+			targets := make([]any, len(returning))
+			for j, f := range returning {
+				if fv := fieldTarget(f, records[i]); fv.CanSet() {
+					targets[j] = fv.Addr().Interface()
+				} else {
+					var dummy any
+					targets[j] = &dummy
+				}
+			}
+			if err := rows.Scan(targets...); err != nil {
+				return fmt.Errorf("failed to scan returned row: %w", err)
+			}
+		}
+		return rows.Err()
+	}
+
+	res, err := d.Conn().ExecContext(ctx, query, values...)
 	if err != nil {
 		return err
 	}
 
 	// Optional: set auto-increment ID ke struct
+	pk, ok := schema.PrimaryField()
+	if !ok {
+		return nil
+	}
+	// When an upsert hits an existing row, LastInsertId is stale (SQLite)
+	// or unrelated (MySQL), so only plain inserts get their IDs
+	if upsert != nil {
+		return nil
+	}
 	id, err := res.LastInsertId()
-	if err == nil {
-		// coba set field ID jika ada
-		rv := reflect.ValueOf(data)
-		if rv.Kind() == reflect.Ptr {
-			rv = rv.Elem()
-		}
-		if rv.Kind() == reflect.Struct {
-			if idField := rv.FieldByName("ID"); idField.IsValid() && idField.CanSet() && idField.Kind() == reflect.Int {
-				idField.SetInt(id)
-			}
+	if err != nil || id == 0 {
+		return nil
+	}
+	if d.Dialect.InsertID() == dialect.LastInsertRowID {
+		id -= int64(len(records) - 1) // ID of the last row; step back to the first
+	}
+	for i, rec := range records {
+		if idField := fieldTarget(pk, rec); idField.CanSet() {
+			setInt(idField, id+int64(i))
 		}
 	}
 
 	return nil
 }
 
+// buildInsert renders the INSERT statement for vmaps and its bind values.
+// On dialects with RETURNING, it reads back the primary key, database
+// defaults and read-only columns the database computes, which are returned
+// in the order of the clause; otherwise returning is nil.
+func buildInsert(d *db.DB, schema *model.Schema, fields []model.Field, vmaps []map[string]any, upsert *upsertClause) (query string, values []any, returning []model.Field) {
+	fieldNames := []string{}
+	for _, f := range fields {
+		fieldNames = append(fieldNames, d.Dialect.Quote(f.Column()))
+	}
+
+	rowsSQL := []string{}
+	for _, vmap := range vmaps {
+		placeholders := []string{}
+		for _, f := range fields {
+			values = append(values, vmap[f.Name])
+			placeholders = append(placeholders, "?")
+		}
+		rowsSQL = append(rowsSQL, "("+strings.Join(placeholders, ", ")+")")
+	}
+
+	query = fmt.Sprintf(
+		"INSERT INTO %s (%s) VALUES %s",
+		d.Dialect.Quote(schema.Table()),
+		strings.Join(fieldNames, ", "),
+		strings.Join(rowsSQL, ", "),
+	)
+	if len(fields) == 0 {
+		// Every column is left to the database, e.g. a lone auto-increment ID
+		query = "INSERT INTO " + d.Dialect.Quote(schema.Table()) + " " + d.Dialect.DefaultValues()
+	}
+	if upsert != nil {
+		query += " " + upsert.sql
+		values = append(values, upsert.vars...)
+	}
+	query = dialect.Rebind(d.Dialect, query)
+
+	if d.Dialect.InsertID() != dialect.Returning {
+		return query, values, nil
+	}
+	// Skipped rows return nothing, so returned rows no longer line up with records
+	if upsert != nil && upsert.doNothing && len(vmaps) > 1 {
+		return query, values, nil
+	}
+
+	for _, f := range schema.Fields {
+		if f.PK || f.HasDefault || f.ReadOnly {
+			returning = append(returning, f)
+		}
+	}
+	if len(returning) > 0 {
+		cols := make([]string, len(returning))
+		for i, f := range returning {
+			cols[i] = d.Dialect.Quote(f.Column())
+		}
+		query += " RETURNING " + strings.Join(cols, ", ")
+	}
+	return query, values, returning
+}
+
+// fieldTarget returns f within rec for assignment, or an invalid Value
+// when rec is not addressable.
+func fieldTarget(f model.Field, rec reflect.Value) reflect.Value {
+	if !rec.CanAddr() {
+		return reflect.Value{}
+	}
+	return f.Target(rec)
+}
+
+// setInt stores id in an integer field of any size or signedness.
+// Non-integer fields, such as string keys, are left untouched.
+func setInt(field reflect.Value, id int64) {
+	switch field.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		field.SetInt(id)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		field.SetUint(uint64(id))
+	}
+}
+
+// isZero reports whether v is nil or the zero value of its type.
+func isZero(v any) bool {
+	return v == nil || reflect.ValueOf(v).IsZero()
+}
+
+// timestamp converts now to the Go type of the timestamp field f:
+// time.Time, *time.Time or unix seconds in an integer.
+func timestamp(f model.Field, now time.Time) reflect.Value {
+	switch f.GoType {
+	case reflect.TypeOf(now):
+		return reflect.ValueOf(now)
+	case reflect.TypeOf(&now):
+		return reflect.ValueOf(&now)
+	case reflect.TypeOf(sql.NullTime{}):
+		return reflect.ValueOf(sql.NullTime{Time: now, Valid: true})
+	default:
+		return reflect.ValueOf(now.Unix()).Convert(f.GoType)
+	}
+}
+
+// parseSchema parses modelRef with the naming strategy configured on d.
+func parseSchema(d *db.DB, modelRef any) (*model.Schema, error) {
+	if modelRef == nil {
+		return nil, fmt.Errorf("model cannot be nil")
+	}
+	return model.ParseWith(modelRef, d.Naming), nil
+}
+
 // Find retrieves all rows for the given schema and maps to dest
-func Find(db *sql.DB, schema any, dest any) error {
+func Find(d *db.DB, schema any, dest any) error {
+	return FindContext(d, context.Background(), schema, dest)
+}
+
+// FindContext retrieves all rows for the given schema using the provided context
+func FindContext(d *db.DB, ctx context.Context, schema any, dest any) error {
 	// Extract table name
-	s := model.Parse(schema)
+	s := model.ParseWith(schema, d.Naming)
 
-	query := fmt.Sprintf("SELECT * FROM %s", s.Table())
-	rows, err := db.Query(query)
+	query := strings.TrimSpace(fmt.Sprintf("SELECT * FROM %s %s", d.Dialect.Quote(s.Table()), scopeWhere(d, s, "")))
+
+	ctx, cancel := d.WithTimeout(ctx)
+	defer cancel()
+
+	rows, err := d.Conn().QueryContext(ctx, query)
 	if err != nil {
 		return fmt.Errorf("query failed: %w", err)
 	}
 	defer rows.Close()
 
-	return utils.ScanRows(rows, dest)
+	return utils.ScanRowsWith(rows, dest, d.Naming)
 }
 
 // First retrieves the first matching row for the given schema and maps to dest.
-func First(db *sql.DB, schema any, dest any, whereClause string, args ...any) error {
-	s := model.Parse(schema)
+func First(d *db.DB, schema any, dest any, whereClause string, args ...any) error {
+	return FirstContext(d, context.Background(), schema, dest, whereClause, args...)
+}
+
+// FirstContext retrieves the first matching row using the provided context.
+func FirstContext(d *db.DB, ctx context.Context, schema any, dest any, whereClause string, args ...any) error {
+	whereClause, args = clause.Expand(whereClause, args)
+	s := model.ParseWith(schema, d.Naming)
+
+	query := fmt.Sprintf("SELECT * FROM %s %s %s", d.Dialect.Quote(s.Table()), scopeWhere(d, s, whereClause), d.Dialect.LimitOffset(1, -1))
+	query = dialect.Rebind(d.Dialect, query)
 
-	query := fmt.Sprintf("SELECT * FROM %s %s LIMIT 1", s.Table(), whereClause)
+	ctx, cancel := d.WithTimeout(ctx)
+	defer cancel()
 
-	rows, err := db.Query(query, args...)
+	rows, err := d.Conn().QueryContext(ctx, query, args...)
 	if err != nil {
 		return fmt.Errorf("query failed: %w", err)
 	}
 	defer rows.Close()
 
-	// Scan ke slice sementara, ambil index 0
-	tmp := reflect.New(reflect.SliceOf(reflect.TypeOf(dest).Elem())).Interface()
+	return utils.ScanFirstWith(rows, dest, d.Naming)
+}
 
-	if err := utils.ScanRows(rows, tmp); err != nil {
-		return err
+// Update updates fields in a table based on a WHERE clause.
+func Update(d *db.DB, schemaRef any, data map[string]any, whereClause string, args ...any) (Result, error) {
+	return UpdateContext(d, context.Background(), schemaRef, data, whereClause, args...)
+}
+
+// UpdateContext updates fields in a table based on a WHERE clause using the provided context.
+// For models with a version field, the version is incremented; if data also
+// holds the version, only rows still at that version are updated and
+// ErrStaleObject is returned when there are none.
+// If d requires affected rows, updating none returns sql.ErrNoRows.
+func UpdateContext(d *db.DB, ctx context.Context, schemaRef any, data map[string]any, whereClause string, args ...any) (Result, error) {
+	query, values, checked, err := buildUpdate(d, schemaRef, data, whereClause, args, true)
+	if err != nil {
+		return Result{}, err
 	}
 
-	tmpVal := reflect.ValueOf(tmp).Elem()
-	if tmpVal.Len() == 0 {
-		return sql.ErrNoRows
+	return execWrite(d, ctx, query, values, checked)
+}
+
+// UpdateColumnsContext sets exactly the columns in data on the rows matching
+// a WHERE clause, without touching UpdatedAt or incrementing the version.
+func UpdateColumnsContext(d *db.DB, ctx context.Context, schemaRef any, data map[string]any, whereClause string, args ...any) (Result, error) {
+	query, values, _, err := buildUpdate(d, schemaRef, data, whereClause, args, false)
+	if err != nil {
+		return Result{}, err
 	}
 
-	reflect.ValueOf(dest).Elem().Set(tmpVal.Index(0))
-	return nil
+	return execWrite(d, ctx, query, values, false)
 }
 
-// Update updates fields in a table based on a WHERE clause.
-func Update(db *sql.DB, schemaRef any, data map[string]any, whereClause string, args ...any) error {
-	schema, err := model.ExtractSchema(schemaRef)
+// UpdateReturningContext updates rows like UpdateContext and scans the
+// updated rows into dest, which must be a pointer to a slice.
+// It returns ErrReturningNotSupported if the dialect has no RETURNING clause.
+func UpdateReturningContext(d *db.DB, ctx context.Context, schemaRef any, dest any, data map[string]any, whereClause string, args ...any) error {
+	if d.Dialect.InsertID() != dialect.Returning {
+		return ErrReturningNotSupported
+	}
+
+	query, values, checked, err := buildUpdate(d, schemaRef, data, whereClause, args, true)
 	if err != nil {
 		return err
 	}
 
-	setClauses := []string{}
-	values := []any{}
+	if err := queryReturning(d, ctx, dest, query, values); err != nil {
+		return err
+	}
+	return checkAffected(d, int64(reflect.ValueOf(dest).Elem().Len()), checked)
+}
 
+// buildUpdate renders the UPDATE statement and its bind values.
+// With track, UpdatedAt is touched and the version incremented.
+// checked reports whether the statement is guarded by an expected version.
+func buildUpdate(d *db.DB, schemaRef any, data map[string]any, whereClause string, args []any, track bool) (query string, values []any, checked bool, err error) {
+	whereClause, args = clause.Expand(whereClause, args)
+	schema, err := parseSchema(d, schemaRef)
+	if err != nil {
+		return "", nil, false, err
+	}
+	if err := checkGlobal(d, schema, whereClause); err != nil {
+		return "", nil, false, err
+	}
+
+	// Keys may be struct field names or column names
+	byColumn := map[string]any{}
 	for key, val := range data {
-		colName := key
-		// Optional: if key is struct field name, convert to snake_case
-		colName = utils.ToSnakeCase(colName)
-		setClauses = append(setClauses, fmt.Sprintf("%s = ?", colName))
+		f, ok := schema.LookUpField(key)
+		if !ok {
+			return "", nil, false, fmt.Errorf("%w %q for table %s", ErrUnknownColumn, key, schema.Table())
+		}
+		if f.ReadOnly {
+			return "", nil, false, fmt.Errorf("column %q of table %s is read-only", key, schema.Table())
+		}
+		if _, dup := byColumn[f.Column()]; dup {
+			return "", nil, false, fmt.Errorf("column %q of table %s is set twice", f.Column(), schema.Table())
+		}
+		byColumn[f.Column()] = val
+	}
+
+	setClauses := []string{}
+	set := map[string]bool{}
+	version, hasVersion := schema.VersionField()
+	hasVersion = hasVersion && track
+	var expected any
+
+	// Follow the field order so the same update always renders the same statement
+	for _, f := range schema.Fields {
+		val, ok := byColumn[f.Column()]
+		if !ok {
+			continue
+		}
+		// The version is never set directly; a given value is the expected one
+		if hasVersion && f.Version {
+			expected, checked = val, true
+			continue
+		}
+		set[f.Column()] = true
+		if p, ok := val.(*clause.Expr); ok && p != nil {
+			val = *p
+		}
+		if e, ok := val.(clause.Expr); ok {
+			setClauses = append(setClauses, d.Dialect.Quote(f.Column())+" = "+e.SQL)
+			values = append(values, e.Vars...)
+			continue
+		}
+		setClauses = append(setClauses, d.Dialect.Quote(f.Column())+" = ?")
 		values = append(values, val)
 	}
 
-	query := fmt.Sprintf(
+	whereClause = scopeWhere(d, schema, whereClause)
+	if hasVersion {
+		col := d.Dialect.Quote(version.Column())
+		setClauses = append(setClauses, col+" = "+col+" + 1")
+	}
+
+	// Touch UpdatedAt unless the caller sets it
+	for _, f := range schema.Fields {
+		if track && f.AutoUpdateTime && !set[f.Column()] {
+			setClauses = append(setClauses, d.Dialect.Quote(f.Column())+" = ?")
+			values = append(values, timestamp(f, d.Now()).Interface())
+		}
+	}
+
+	if len(setClauses) == 0 {
+		return "", nil, false, fmt.Errorf("no columns to update on %s", schema.Table())
+	}
+
+	if checked {
+		whereClause = addCondition(whereClause, d.Dialect.Quote(version.Column())+" = ?")
+		values = append(values, expected)
+	}
+
+	query = fmt.Sprintf(
 		"UPDATE %s SET %s %s",
-		schema.Table(),
+		d.Dialect.Quote(schema.Table()),
 		strings.Join(setClauses, ", "),
 		whereClause,
 	)
+	query = dialect.Rebind(d.Dialect, query)
 
 	values = append(values, args...) // add WHERE args
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-
-	_, err = db.ExecContext(ctx, query, values...)
-	return err
+	return query, values, checked, nil
 }
 
 // Delete removes rows from a table based on a WHERE clause.
-func Delete(db *sql.DB, schemaRef any, whereClause string, args ...any) error {
-	schema, err := model.ExtractSchema(schemaRef)
+func Delete(d *db.DB, schemaRef any, whereClause string, args ...any) (Result, error) {
+	return DeleteContext(d, context.Background(), schemaRef, whereClause, args...)
+}
+
+// DeleteContext removes rows from a table based on a WHERE clause using the provided context.
+// Models with a DeletedAt field are soft-deleted unless d is unscoped.
+// If d requires affected rows, deleting none returns sql.ErrNoRows.
+func DeleteContext(d *db.DB, ctx context.Context, schemaRef any, whereClause string, args ...any) (Result, error) {
+	whereClause, args = clause.Expand(whereClause, args)
+	schema, err := parseSchema(d, schemaRef)
+	if err != nil {
+		return Result{}, err
+	}
+	if err := checkGlobal(d, schema, whereClause); err != nil {
+		return Result{}, err
+	}
+
+	query, args := buildDelete(d, schema, whereClause, args, d.Now())
+	return execWrite(d, ctx, query, args, false)
+}
+
+// buildDelete renders the DELETE statement and its bind values, or the
+// UPDATE setting DeletedAt to now for soft-deleted models unless d is unscoped.
+func buildDelete(d *db.DB, schema *model.Schema, whereClause string, args []any, now time.Time) (string, []any) {
+	if f, ok := schema.DeletedAtField(); ok && !d.Unscoped {
+		query := fmt.Sprintf(
+			"UPDATE %s SET %s = ? %s",
+			d.Dialect.Quote(schema.Table()),
+			d.Dialect.Quote(f.Column()),
+			scopeWhere(d, schema, whereClause),
+		)
+		return dialect.Rebind(d.Dialect, query), append([]any{now}, args...)
+	}
+
+	query := fmt.Sprintf("DELETE FROM %s %s", d.Dialect.Quote(schema.Table()), whereClause)
+	return dialect.Rebind(d.Dialect, query), args
+}
+
+// Restore undeletes soft-deleted rows matching a WHERE clause.
+func Restore(d *db.DB, schemaRef any, whereClause string, args ...any) (Result, error) {
+	return RestoreContext(d, context.Background(), schemaRef, whereClause, args...)
+}
+
+// RestoreContext undeletes soft-deleted rows matching a WHERE clause using the provided context.
+// It returns ErrNoSoftDelete if the model has no DeletedAt field.
+func RestoreContext(d *db.DB, ctx context.Context, schemaRef any, whereClause string, args ...any) (Result, error) {
+	whereClause, args = clause.Expand(whereClause, args)
+	schema, err := parseSchema(d, schemaRef)
+	if err != nil {
+		return Result{}, err
+	}
+
+	f, ok := schema.DeletedAtField()
+	if !ok {
+		return Result{}, fmt.Errorf("%w: %s", ErrNoSoftDelete, schema.Table())
+	}
+	if err := checkGlobal(d, schema, whereClause); err != nil {
+		return Result{}, err
+	}
+
+	col := d.Dialect.Quote(f.Column())
+	query := fmt.Sprintf(
+		"UPDATE %s SET %s = NULL %s",
+		d.Dialect.Quote(schema.Table()),
+		col,
+		addCondition(whereClause, col+" IS NOT NULL"),
+	)
+	query = dialect.Rebind(d.Dialect, query)
+
+	return execWrite(d, ctx, query, args, false)
+}
+
+// checkGlobal returns ErrMissingWhereClause if whereClause has no condition
+// and d does not allow global updates.
+func checkGlobal(d *db.DB, schema *model.Schema, whereClause string) error {
+	if d.AllowGlobalUpdate || hasCondition(whereClause) {
+		return nil
+	}
+	return fmt.Errorf("%w on %s", ErrMissingWhereClause, schema.Table())
+}
+
+// hasCondition reports whether whereClause, a raw clause such as
+// "WHERE id = ? LIMIT 1", has a WHERE condition.
+func hasCondition(whereClause string) bool {
+	clause := strings.TrimSpace(whereClause)
+	return len(clause) > 5 && strings.EqualFold(clause[:5], "WHERE") && strings.ContainsAny(clause[5:6], " \t\n(")
+}
+
+// scopeWhere adds the soft-delete condition to whereClause unless d is
+// unscoped or the model has no DeletedAt field.
+func scopeWhere(d *db.DB, schema *model.Schema, whereClause string) string {
+	f, ok := schema.DeletedAtField()
+	if !ok || d.Unscoped {
+		return whereClause
+	}
+	return addCondition(whereClause, d.Dialect.Quote(f.Column())+" IS NULL")
+}
+
+// trailingClauses end the conditions of a raw WHERE clause.
+var trailingClauses = []string{" GROUP BY ", " HAVING ", " ORDER BY ", " LIMIT ", " OFFSET ", " FOR UPDATE", " RETURNING "}
+
+// addCondition ANDs cond into whereClause, a raw clause such as
+// "WHERE age > ? OR name = ? ORDER BY id", keeping the existing conditions
+// together in parentheses and any trailing ORDER BY or LIMIT in place.
+func addCondition(whereClause string, cond string) string {
+	clause := strings.TrimSpace(whereClause)
+	if len(clause) < 6 || !strings.EqualFold(clause[:5], "WHERE") || !strings.ContainsAny(clause[5:6], " \t\n(") {
+		return strings.TrimSpace("WHERE " + cond + " " + clause)
+	}
+
+	rest := clause[5:]
+	end := len(rest)
+	upper := strings.ToUpper(rest)
+	depth := 0
+	var quote byte
+	// Clauses inside parentheses belong to a subquery; quoted text is skipped
+	for i := 0; i < len(upper) && end == len(rest); i++ {
+		c := upper[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+		case c == ' ' && depth == 0:
+			for _, kw := range trailingClauses {
+				if strings.HasPrefix(upper[i:], kw) {
+					end = i
+					break
+				}
+			}
+		}
+	}
+	return "WHERE " + cond + " AND (" + strings.TrimSpace(rest[:end]) + ")" + rest[end:]
+}
+
+// DeleteReturningContext deletes rows like DeleteContext and scans the
+// deleted rows into dest, which must be a pointer to a slice.
+// It returns ErrReturningNotSupported if the dialect has no RETURNING clause.
+func DeleteReturningContext(d *db.DB, ctx context.Context, schemaRef any, dest any, whereClause string, args ...any) error {
+	if d.Dialect.InsertID() != dialect.Returning {
+		return ErrReturningNotSupported
+	}
+	whereClause, args = clause.Expand(whereClause, args)
+
+	schema, err := parseSchema(d, schemaRef)
 	if err != nil {
 		return err
 	}
+	if err := checkGlobal(d, schema, whereClause); err != nil {
+		return err
+	}
 
-	query := fmt.Sprintf("DELETE FROM %s %s", schema.Table(), whereClause)
+	query, args := buildDelete(d, schema, whereClause, args, d.Now())
+	if err := queryReturning(d, ctx, dest, query, args); err != nil {
+		return err
+	}
+	return checkAffected(d, int64(reflect.ValueOf(dest).Elem().Len()), false)
+}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+// queryReturning runs query with a RETURNING * clause and scans the rows into dest.
+func queryReturning(d *db.DB, ctx context.Context, dest any, query string, args []any) error {
+	ctx, cancel := d.WithTimeout(ctx)
 	defer cancel()
 
-	_, err = db.ExecContext(ctx, query, args...)
-	return err
+	rows, err := d.Conn().QueryContext(ctx, query+" RETURNING *", args...)
+	if err != nil {
+		return fmt.Errorf("query failed: %w", err)
+	}
+	defer rows.Close()
+
+	return utils.ScanRowsWith(rows, dest, d.Naming)
 }
 
 // RawSQL runs a raw SQL query with default context (no timeout)
-func RawSQL(db *sql.DB, query string, args ...any) (*sql.Rows, error) {
-	return db.Query(query, args...)
+func RawSQL(d *db.DB, query string, args ...any) (*sql.Rows, error) {
+	return RawSQLContext(d, context.Background(), query, args...)
 }
 
 // RawSQLContext runs a raw SQL query with a provided context
-func RawSQLContext(db *sql.DB, ctx context.Context, query string, args ...any) (*sql.Rows, error) {
-	return db.QueryContext(ctx, query, args...)
+func RawSQLContext(d *db.DB, ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	query, args = clause.Expand(query, args)
+	return d.Conn().QueryContext(ctx, query, args...)
 }
@@ -0,0 +1,178 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/adipras/torm/clause"
+	"github.com/adipras/torm/db"
+	"github.com/adipras/torm/dialect"
+	"github.com/adipras/torm/model"
+)
+
+func TestAddCondition(t *testing.T) {
+	cond := "`deleted_at` IS NULL"
+	cases := map[string]string{
+		"":                                    "WHERE `deleted_at` IS NULL",
+		"WHERE id = ? OR age > ?":             "WHERE `deleted_at` IS NULL AND (id = ? OR age > ?)",
+		"WHERE id > ? ORDER BY id":            "WHERE `deleted_at` IS NULL AND (id > ?) ORDER BY id",
+		"ORDER BY id LIMIT 1":                 "WHERE `deleted_at` IS NULL ORDER BY id LIMIT 1",
+		"WHERE note = 'sorted order by date'": "WHERE `deleted_at` IS NULL AND (note = 'sorted order by date')",
+		"WHERE `limit` = ? LIMIT 2":           "WHERE `deleted_at` IS NULL AND (`limit` = ?) LIMIT 2",
+		"WHERE id IN (SELECT id FROM t ORDER BY id LIMIT 2)": "WHERE `deleted_at` IS NULL AND (id IN (SELECT id FROM t ORDER BY id LIMIT 2))",
+	}
+	for in, want := range cases {
+		if got := addCondition(in, cond); got != want {
+			t.Errorf("addCondition(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+type stock struct {
+	ID        int        `db:"id"`
+	SKU       string     `db:"sku,unique"`
+	Qty       int        `db:"qty"`
+	DeletedAt *time.Time `db:"deleted_at"`
+}
+
+func TestBuildUpsertUpdateAll(t *testing.T) {
+	cases := []struct {
+		d    dialect.Dialect
+		want string
+	}{
+		{dialect.MySQL{}, "ON DUPLICATE KEY UPDATE `qty` = VALUES(`qty`)"},
+		{dialect.Postgres{}, `ON CONFLICT ("sku") DO UPDATE SET "qty" = EXCLUDED."qty"`},
+	}
+	for _, c := range cases {
+		d := &db.DB{Dialect: c.d}
+		schema := model.ParseWith(&stock{}, d.Naming)
+		// The zero ID is left out of the insert
+		up, err := buildUpsert(d, schema, schema.Fields[1:], clause.OnConflict{Columns: []string{"sku"}, UpdateAll: true})
+		if err != nil {
+			t.Fatalf("%s: buildUpsert() failed: %v", c.d.Name(), err)
+		}
+		if up.sql != c.want {
+			t.Errorf("%s: buildUpsert() = %s, want %s", c.d.Name(), up.sql, c.want)
+		}
+	}
+}
+
+func TestBuildInsertReturning(t *testing.T) {
+	vmaps := []map[string]any{{"SKU": "a", "Qty": 1}, {"SKU": "b", "Qty": 2}}
+	pg := &db.DB{Dialect: dialect.Postgres{}}
+	schema := model.ParseWith(&stock{}, pg.Naming)
+	fields := schema.Fields[1:3]
+
+	query, values, returning := buildInsert(pg, schema, fields, vmaps, nil)
+	if want := `INSERT INTO "stocks" ("sku", "qty") VALUES ($1, $2), ($3, $4) RETURNING "id"`; query != want {
+		t.Errorf("buildInsert() = %s, want %s", query, want)
+	}
+	if len(values) != 4 || len(returning) != 1 || returning[0].Name != "ID" {
+		t.Errorf("expected 4 values and the ID read back, got %v, %+v", values, returning)
+	}
+
+	// Rows skipped by DO NOTHING return nothing, so only a single row is read back
+	up, err := buildUpsert(pg, schema, fields, clause.OnConflict{DoNothing: true})
+	if err != nil {
+		t.Fatalf("buildUpsert() failed: %v", err)
+	}
+	if query, _, returning := buildInsert(pg, schema, fields, vmaps, up); len(returning) != 0 ||
+		query != `INSERT INTO "stocks" ("sku", "qty") VALUES ($1, $2), ($3, $4) ON CONFLICT DO NOTHING` {
+		t.Errorf("unexpected DO NOTHING batch insert: %s, %+v", query, returning)
+	}
+	if query, _, returning := buildInsert(pg, schema, fields, vmaps[:1], up); len(returning) != 1 ||
+		query != `INSERT INTO "stocks" ("sku", "qty") VALUES ($1, $2) ON CONFLICT DO NOTHING RETURNING "id"` {
+		t.Errorf("unexpected DO NOTHING single insert: %s, %+v", query, returning)
+	}
+
+	my := &db.DB{Dialect: dialect.MySQL{}}
+	if query, _, returning := buildInsert(my, schema, fields, vmaps, nil); returning != nil ||
+		query != "INSERT INTO `stocks` (`sku`, `qty`) VALUES (?, ?), (?, ?)" {
+		t.Errorf("unexpected MySQL insert: %s, %+v", query, returning)
+	}
+}
+
+func TestBuildUpsertConflict(t *testing.T) {
+	for _, dl := range []dialect.Dialect{dialect.Postgres{}, dialect.SQLite{}} {
+		d := &db.DB{Dialect: dl}
+		schema := model.ParseWith(&stock{}, d.Naming)
+		fields := schema.Fields[1:3]
+
+		cases := []struct {
+			conflict clause.OnConflict
+			want     string
+		}{
+			{clause.OnConflict{DoNothing: true}, `ON CONFLICT DO NOTHING`},
+			{clause.OnConflict{Columns: []string{"SKU"}, DoNothing: true}, `ON CONFLICT ("sku") DO NOTHING`},
+			// DO UPDATE needs a target: the primary key by default
+			{clause.OnConflict{DoUpdates: []string{"qty"}}, `ON CONFLICT ("id") DO UPDATE SET "qty" = EXCLUDED."qty"`},
+			{clause.OnConflict{Columns: []string{"sku"}, Set: map[string]any{"qty": clause.Expr{SQL: "qty + ?", Vars: []any{1}}}}, `ON CONFLICT ("sku") DO UPDATE SET "qty" = qty + ?`},
+		}
+		for _, c := range cases {
+			up, err := buildUpsert(d, schema, fields, c.conflict)
+			if err != nil {
+				t.Fatalf("%s: buildUpsert(%+v) failed: %v", dl.Name(), c.conflict, err)
+			}
+			if up.sql != c.want {
+				t.Errorf("%s: buildUpsert(%+v) = %s, want %s", dl.Name(), c.conflict, up.sql, c.want)
+			}
+		}
+
+		if _, err := buildUpsert(d, schema, fields, clause.OnConflict{Columns: []string{"barcode"}}); !errors.Is(err, ErrUnknownColumn) {
+			t.Errorf("%s: expected ErrUnknownColumn for an unknown target, got %v", dl.Name(), err)
+		}
+	}
+}
+
+func TestReturningStatements(t *testing.T) {
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	pg := &db.DB{Dialect: dialect.Postgres{}, NowFunc: func() time.Time { return now }}
+
+	query, values, _, err := buildUpdate(pg, &stock{}, map[string]any{"qty": 1}, "WHERE sku = ?", []any{"a"}, true)
+	if err != nil {
+		t.Fatalf("buildUpdate() failed: %v", err)
+	}
+	if want := `UPDATE "stocks" SET "qty" = $1 WHERE "deleted_at" IS NULL AND (sku = $2)`; query != want || len(values) != 2 {
+		t.Errorf("buildUpdate() = %s %v, want %s", query, values, want)
+	}
+
+	// A soft delete returns the rows it marks as deleted
+	query, values = buildDelete(pg, model.ParseWith(&stock{}, pg.Naming), "WHERE sku = ?", []any{"a"}, now)
+	if want := `UPDATE "stocks" SET "deleted_at" = $1 WHERE "deleted_at" IS NULL AND (sku = $2)`; query != want || values[0] != now {
+		t.Errorf("buildDelete() = %s %v, want %s", query, values, want)
+	}
+
+	my := &db.DB{Dialect: dialect.MySQL{}}
+	var dest []stock
+	if err := UpdateReturningContext(my, context.Background(), &stock{}, &dest, map[string]any{"qty": 1}, "WHERE id = ?", 1); !errors.Is(err, ErrReturningNotSupported) {
+		t.Errorf("expected ErrReturningNotSupported from UpdateReturning, got %v", err)
+	}
+	if err := DeleteReturningContext(my, context.Background(), &stock{}, &dest, "WHERE id = ?", 1); !errors.Is(err, ErrReturningNotSupported) {
+		t.Errorf("expected ErrReturningNotSupported from DeleteReturning, got %v", err)
+	}
+}
+
+type counter struct {
+	ID int `db:"id"`
+}
+
+func TestBuildInsertDefaultValues(t *testing.T) {
+	cases := []struct {
+		d    dialect.Dialect
+		want string
+	}{
+		{dialect.MySQL{}, "INSERT INTO `counters` () VALUES ()"},
+		{dialect.Postgres{}, `INSERT INTO "counters" DEFAULT VALUES RETURNING "id"`},
+		{dialect.SQLite{}, `INSERT INTO "counters" DEFAULT VALUES`},
+	}
+	for _, c := range cases {
+		d := &db.DB{Dialect: c.d}
+		schema := model.ParseWith(&counter{}, d.Naming)
+		query, values, _ := buildInsert(d, schema, nil, []map[string]any{{}}, nil)
+		if query != c.want || len(values) != 0 {
+			t.Errorf("%s: buildInsert() = %s %v, want %s", c.d.Name(), query, values, c.want)
+		}
+	}
+}
@@ -0,0 +1,370 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/adipras/torm/clause"
+	"github.com/adipras/torm/db"
+	"github.com/adipras/torm/dialect"
+	"github.com/adipras/torm/model"
+	"github.com/adipras/torm/utils"
+)
+
+// ErrMissingPrimaryKey is returned when a model has no primary key field,
+// or when a record's primary key is needed but is zero.
+var ErrMissingPrimaryKey = errors.New("missing primary key")
+
+// FindByIDContext retrieves the row whose primary key equals id into dest, a pointer to struct.
+// It returns sql.ErrNoRows if there is no such row.
+func FindByIDContext(d *db.DB, ctx context.Context, dest any, id any) error {
+	schema := model.ParseWith(dest, d.Naming)
+	pk, ok := schema.PrimaryField()
+	if !ok {
+		return fmt.Errorf("%w: %s needs a single primary key field, use FindByKey", ErrMissingPrimaryKey, schema.Table())
+	}
+
+	whereClause := "WHERE " + d.Dialect.Quote(pk.Column()) + " = ?"
+	return FirstContext(d, ctx, dest, dest, whereClause, id)
+}
+
+// FindByKeyContext retrieves the row identified by key into dest, a pointer to struct.
+// key holds the primary key values, either as a map keyed by Go field or
+// column name, as a struct with the primary key fields set, or as a plain
+// value for single-column keys. It returns sql.ErrNoRows if there is no such row.
+func FindByKeyContext(d *db.DB, ctx context.Context, dest any, key any) error {
+	schema := model.ParseWith(dest, d.Naming)
+	vals, err := keyValues(schema, key)
+	if err != nil {
+		return err
+	}
+
+	whereClause, args := keysWhere(d, schema, [][]any{vals})
+	return FirstContext(d, ctx, dest, dest, whereClause, args...)
+}
+
+// FindByKeysContext retrieves every row identified by keys, a slice of keys
+// as accepted by FindByKeyContext, into dest, a pointer to slice.
+func FindByKeysContext(d *db.DB, ctx context.Context, dest any, keys any) error {
+	destVal := reflect.ValueOf(dest)
+	if destVal.Kind() != reflect.Ptr || destVal.Elem().Kind() != reflect.Slice {
+		return errors.New("dest must be a pointer to slice")
+	}
+	schema := model.ParseWith(reflect.New(destVal.Elem().Type().Elem()).Interface(), d.Naming)
+
+	kv := reflect.ValueOf(keys)
+	if kv.Kind() != reflect.Slice && kv.Kind() != reflect.Array {
+		return errors.New("keys must be a slice")
+	}
+	if kv.Len() == 0 {
+		return nil
+	}
+
+	tuples := make([][]any, kv.Len())
+	for i := range tuples {
+		vals, err := keyValues(schema, kv.Index(i).Interface())
+		if err != nil {
+			return err
+		}
+		tuples[i] = vals
+	}
+
+	// Stay within the dialect's bind variable limit, like inserts do
+	chunk := max(d.Dialect.MaxPlaceholders()/len(schema.PrimaryFields), 1)
+	for start := 0; start < len(tuples); start += chunk {
+		end := min(start+chunk, len(tuples))
+		if err := findKeys(d, ctx, schema, tuples[start:end], dest); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// findKeys appends the rows identified by tuples to dest.
+func findKeys(d *db.DB, ctx context.Context, schema *model.Schema, tuples [][]any, dest any) error {
+	whereClause, args := keysWhere(d, schema, tuples)
+	query := fmt.Sprintf("SELECT * FROM %s %s", d.Dialect.Quote(schema.Table()), scopeWhere(d, schema, whereClause))
+	query = dialect.Rebind(d.Dialect, query)
+
+	ctx, cancel := d.WithTimeout(ctx)
+	defer cancel()
+
+	rows, err := d.Conn().QueryContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("query failed: %w", err)
+	}
+	defer rows.Close()
+
+	return utils.ScanRowsWith(rows, dest, d.Naming)
+}
+
+// SaveContext inserts obj if its primary key is zero, otherwise it updates
+// every other column of the row with that primary key. When the update
+// matches no row, as for a key set by the caller, obj is inserted instead
+// unless d requires affected rows. A versioned obj is only inserted while its
+// version is zero; otherwise a missed update is reported as ErrStaleObject.
+// obj must be a pointer to struct so a generated key can be set on insert.
+func SaveContext(d *db.DB, ctx context.Context, obj any) error {
+	rv, err := recordValue(obj)
+	if err != nil {
+		return err
+	}
+
+	schema := model.ParseWith(obj, d.Naming)
+	if len(schema.PrimaryFields) == 0 {
+		return fmt.Errorf("%w: %s has no primary key field", ErrMissingPrimaryKey, schema.Table())
+	}
+
+	whereClause, args, err := primaryKeyWhere(d, schema, rv)
+	if errors.Is(err, ErrMissingPrimaryKey) {
+		return CreateContext(d, ctx, obj, obj)
+	}
+	if err != nil {
+		return err
+	}
+
+	setClauses := []string{}
+	values := []any{}
+	now := d.Now()
+	version, versioned := schema.VersionField()
+	for _, f := range schema.Fields {
+		// Deleting and restoring are left to Delete and Restore
+		if f.PK || f.ReadOnly || f.SoftDelete {
+			continue
+		}
+		if f.Version {
+			col := d.Dialect.Quote(f.Column())
+			setClauses = append(setClauses, col+" = "+col+" + 1")
+			continue
+		}
+		if f.AutoUpdateTime {
+			f.Target(rv).Set(timestamp(f, now))
+		}
+		val := f.ValueOf(rv).Interface()
+		// Keep the database's value for unset defaults and creation times
+		if (f.HasDefault || f.AutoCreateTime) && isZero(val) {
+			continue
+		}
+		setClauses = append(setClauses, d.Dialect.Quote(f.Column())+" = ?")
+		values = append(values, val)
+	}
+	if len(setClauses) == 0 {
+		// Only key columns: make sure the row exists
+		return insertIfAbsent(d, ctx, schema, obj)
+	}
+
+	whereClause = scopeWhere(d, schema, whereClause)
+
+	// Only update the row if nobody else has since the object was read
+	if versioned {
+		whereClause += " AND " + d.Dialect.Quote(version.Column()) + " = ?"
+		args = append(args, version.ValueOf(rv).Interface())
+	}
+
+	query := fmt.Sprintf(
+		"UPDATE %s SET %s %s",
+		d.Dialect.Quote(schema.Table()),
+		strings.Join(setClauses, ", "),
+		whereClause,
+	)
+	query = dialect.Rebind(d.Dialect, query)
+	values = append(values, args...)
+
+	res, err := execWrite(d, ctx, query, values, versioned)
+	// With RequireAffected, a missing row is reported as sql.ErrNoRows instead
+	if !versioned && res.RowsAffected == 0 && err == nil {
+		return insertIfAbsent(d, ctx, schema, obj)
+	}
+	// Create starts versions at 1, so a zero version was never saved
+	if errors.Is(err, ErrStaleObject) && isZero(version.ValueOf(rv).Interface()) {
+		return CreateContext(d, ctx, obj, obj)
+	}
+	if err != nil || !versioned {
+		return err
+	}
+
+	fv := version.Target(rv)
+	if fv.CanInt() {
+		setInt(fv, fv.Int()+1)
+	} else {
+		setInt(fv, int64(fv.Uint())+1)
+	}
+	return nil
+}
+
+// insertIfAbsent inserts obj unless a row with its primary key exists.
+// MySQL also reports no affected rows for an unchanged row, so a missed
+// update alone does not mean the row is missing.
+func insertIfAbsent(d *db.DB, ctx context.Context, schema *model.Schema, obj any) error {
+	conflict := clause.OnConflict{DoNothing: true}
+	for _, f := range schema.PrimaryFields {
+		conflict.Columns = append(conflict.Columns, f.Name)
+	}
+	return UpsertContext(d, ctx, obj, obj, conflict)
+}
+
+// DeleteModelContext deletes the row identified by obj's primary key.
+// A soft-deleted obj gets the same DeletedAt as its row.
+func DeleteModelContext(d *db.DB, ctx context.Context, obj any) error {
+	rv, err := recordValue(obj)
+	if err != nil {
+		return err
+	}
+
+	schema := model.ParseWith(obj, d.Naming)
+	whereClause, args, err := primaryKeyWhere(d, schema, rv)
+	if err != nil {
+		return err
+	}
+
+	now := d.Now()
+	query, args := buildDelete(d, schema, whereClause, args, now)
+	if _, err := execWrite(d, ctx, query, args, false); err != nil {
+		return err
+	}
+
+	if f, ok := schema.DeletedAtField(); ok && !d.Unscoped {
+		f.Target(rv).Set(timestamp(f, now))
+	}
+	return nil
+}
+
+// ReloadContext re-reads obj from the row identified by its primary key.
+// It returns sql.ErrNoRows if the row no longer exists.
+func ReloadContext(d *db.DB, ctx context.Context, obj any) error {
+	rv, err := recordValue(obj)
+	if err != nil {
+		return err
+	}
+
+	schema := model.ParseWith(obj, d.Naming)
+	whereClause, args, err := primaryKeyWhere(d, schema, rv)
+	if err != nil {
+		return err
+	}
+
+	return FirstContext(d, ctx, obj, obj, whereClause, args...)
+}
+
+// recordValue returns the struct obj points to.
+func recordValue(obj any) (reflect.Value, error) {
+	rv := reflect.ValueOf(obj)
+	if rv.Kind() != reflect.Ptr || rv.Elem().Kind() != reflect.Struct {
+		return reflect.Value{}, errors.New("model must be a pointer to struct")
+	}
+	return rv.Elem(), nil
+}
+
+// primaryKeyWhere renders the WHERE clause matching rv's primary key.
+// It returns ErrMissingPrimaryKey if the schema has no key or the key is zero.
+// A composite key is only considered zero when all of its columns are.
+func primaryKeyWhere(d *db.DB, schema *model.Schema, rv reflect.Value) (string, []any, error) {
+	if len(schema.PrimaryFields) == 0 {
+		return "", nil, fmt.Errorf("%w: %s has no primary key field", ErrMissingPrimaryKey, schema.Table())
+	}
+
+	vals := []any{}
+	allZero := true
+	for _, f := range schema.PrimaryFields {
+		val := f.ValueOf(rv).Interface()
+		allZero = allZero && isZero(val)
+		vals = append(vals, val)
+	}
+	if allZero {
+		return "", nil, fmt.Errorf("%w: %s primary key is zero", ErrMissingPrimaryKey, schema.Table())
+	}
+
+	whereClause, args := keysWhere(d, schema, [][]any{vals})
+	return whereClause, args, nil
+}
+
+// keyValues extracts the primary key values from key, ordered like schema.PrimaryFields.
+// key is a map keyed by Go field or column name, a struct (or pointer to one)
+// with the primary key fields, or a plain value for a single-column key.
+func keyValues(schema *model.Schema, key any) ([]any, error) {
+	if len(schema.PrimaryFields) == 0 {
+		return nil, fmt.Errorf("%w: %s has no primary key field", ErrMissingPrimaryKey, schema.Table())
+	}
+
+	kv := reflect.ValueOf(key)
+	if kv.Kind() == reflect.Ptr && kv.Elem().Kind() == reflect.Struct {
+		kv = kv.Elem()
+	}
+
+	vals := make([]any, len(schema.PrimaryFields))
+	switch {
+	case kv.Kind() == reflect.Map && kv.Type().Key().Kind() == reflect.String:
+		if kv.Len() != len(schema.PrimaryFields) {
+			return nil, fmt.Errorf("key for %s must have exactly the primary key columns", schema.Table())
+		}
+		for i, f := range schema.PrimaryFields {
+			v := kv.MapIndex(reflect.ValueOf(f.Name))
+			if !v.IsValid() {
+				v = kv.MapIndex(reflect.ValueOf(f.Column()))
+			}
+			if !v.IsValid() {
+				return nil, fmt.Errorf("key for %s is missing %s", schema.Table(), f.Column())
+			}
+			vals[i] = v.Interface()
+		}
+	case kv.Kind() == reflect.Struct:
+		for i, f := range schema.PrimaryFields {
+			v := kv.FieldByName(f.Name)
+			if !v.IsValid() {
+				return nil, fmt.Errorf("key for %s is missing field %s", schema.Table(), f.Name)
+			}
+			vals[i] = v.Interface()
+		}
+	case len(schema.PrimaryFields) == 1:
+		vals[0] = key
+	default:
+		return nil, fmt.Errorf("key for %s must be a map or struct with %d primary key values", schema.Table(), len(schema.PrimaryFields))
+	}
+
+	return vals, nil
+}
+
+// keysWhere renders a WHERE clause matching any of the primary key tuples.
+// It uses "pk IN (...)" for single-column keys and "(a, b) IN ((...), ...)"
+// for composite keys, falling back to OR-ed conditions on dialects without
+// row-value IN support.
+func keysWhere(d *db.DB, schema *model.Schema, tuples [][]any) (string, []any) {
+	cols := make([]string, len(schema.PrimaryFields))
+	for i, f := range schema.PrimaryFields {
+		cols[i] = d.Dialect.Quote(f.Column())
+	}
+
+	args := []any{}
+	for _, t := range tuples {
+		args = append(args, t...)
+	}
+
+	placeholders := "(" + strings.TrimSuffix(strings.Repeat("?, ", len(cols)), ", ") + ")"
+
+	if len(tuples) == 1 {
+		conds := make([]string, len(cols))
+		for i, c := range cols {
+			conds[i] = c + " = ?"
+		}
+		return "WHERE " + strings.Join(conds, " AND "), args
+	}
+
+	if len(cols) == 1 {
+		return "WHERE " + cols[0] + " IN (" + strings.TrimSuffix(strings.Repeat("?, ", len(tuples)), ", ") + ")", args
+	}
+
+	if d.Dialect.RowValueIn() {
+		list := strings.TrimSuffix(strings.Repeat(placeholders+", ", len(tuples)), ", ")
+		return "WHERE (" + strings.Join(cols, ", ") + ") IN (" + list + ")", args
+	}
+
+	conds := make([]string, len(cols))
+	for i, c := range cols {
+		conds[i] = c + " = ?"
+	}
+	tupleCond := "(" + strings.Join(conds, " AND ") + ")"
+	return "WHERE " + strings.TrimSuffix(strings.Repeat(tupleCond+" OR ", len(tuples)), " OR "), args
+}
@@ -0,0 +1,40 @@
+package executor
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/adipras/torm/db"
+	"github.com/adipras/torm/dialect"
+	"github.com/adipras/torm/model"
+)
+
+type seat struct {
+	Match  int `db:"match_id,pk"`
+	Number int `db:"number,pk"`
+}
+
+func TestKeysWhere(t *testing.T) {
+	tuples := [][]any{{1, 2}, {1, 3}}
+	cases := []struct {
+		d    dialect.Dialect
+		want string
+	}{
+		{dialect.MySQL{}, "WHERE (`match_id`, `number`) IN ((?, ?), (?, ?))"},
+		{dialect.Postgres{}, `WHERE ("match_id", "number") IN ((?, ?), (?, ?))`},
+		// SQLite has no row-value IN
+		{dialect.SQLite{}, `WHERE ("match_id" = ? AND "number" = ?) OR ("match_id" = ? AND "number" = ?)`},
+	}
+	for _, c := range cases {
+		d := &db.DB{Dialect: c.d}
+		where, args := keysWhere(d, model.ParseWith(&seat{}, d.Naming), tuples)
+		if where != c.want || !reflect.DeepEqual(args, []any{1, 2, 1, 3}) {
+			t.Errorf("%s: keysWhere() = %s %v, want %s", c.d.Name(), where, args, c.want)
+		}
+	}
+
+	d := &db.DB{Dialect: dialect.SQLite{}}
+	if where, _ := keysWhere(d, model.ParseWith(&seat{}, d.Naming), tuples[:1]); where != `WHERE "match_id" = ? AND "number" = ?` {
+		t.Errorf("single key keysWhere() = %s", where)
+	}
+}
@@ -0,0 +1,53 @@
+package executor
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/adipras/torm/db"
+)
+
+// Result describes the outcome of an UPDATE or DELETE statement.
+type Result struct {
+	// RowsAffected is the number of rows the statement changed. MySQL only
+	// counts rows whose values actually changed unless the DSN sets
+	// clientFoundRows=true.
+	RowsAffected int64
+
+	// LastInsertID is the last generated ID, if the driver reports one.
+	LastInsertID int64
+}
+
+// execWrite runs a write statement and collects its Result.
+// versioned reports whether the statement is guarded by an expected version.
+func execWrite(d *db.DB, ctx context.Context, query string, args []any, versioned bool) (Result, error) {
+	ctx, cancel := d.WithTimeout(ctx)
+	defer cancel()
+
+	res, err := d.Conn().ExecContext(ctx, query, args...)
+	if err != nil {
+		return Result{}, err
+	}
+
+	r := Result{}
+	if r.RowsAffected, err = res.RowsAffected(); err != nil {
+		return r, err
+	}
+	r.LastInsertID, _ = res.LastInsertId() // not every driver reports one
+
+	return r, checkAffected(d, r.RowsAffected, versioned)
+}
+
+// checkAffected returns ErrStaleObject if a version-guarded statement
+// affected no rows, or sql.ErrNoRows if d requires affected rows.
+func checkAffected(d *db.DB, n int64, versioned bool) error {
+	switch {
+	case n > 0:
+		return nil
+	case versioned:
+		return ErrStaleObject
+	case d.RequireAffected:
+		return sql.ErrNoRows
+	}
+	return nil
+}
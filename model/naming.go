@@ -0,0 +1,131 @@
+package model
+
+import (
+	"strings"
+)
+
+// Tabler is implemented by models that choose their own table name.
+// The returned name is used as is, without prefix or pluralization.
+type Tabler interface {
+	TableName() string
+}
+
+// NamingStrategy maps Go struct and field names to table and column names.
+// A nil *NamingStrategy uses the defaults: snake_case columns and
+// pluralized snake_case table names.
+type NamingStrategy struct {
+	TablePrefix   string                    // prepended to every derived table name, e.g. "app_"
+	SingularTable bool                      // use User -> user instead of User -> users
+	Irregular     map[string]string         // extra singular -> plural words, e.g. "datum": "data"
+	ColumnMapper  func(field string) string // overrides the snake_case column mapping
+}
+
+// irregularPlurals are the words whose plural does not follow the suffix rules.
+var irregularPlurals = map[string]string{
+	"person": "people",
+	"man":    "men",
+	"woman":  "women",
+	"child":  "children",
+	"mouse":  "mice",
+	"goose":  "geese",
+	"tooth":  "teeth",
+	"foot":   "feet",
+	"ox":     "oxen",
+}
+
+// uncountable words have the same singular and plural form.
+var uncountable = map[string]bool{
+	"equipment": true,
+	"info":      true,
+	"money":     true,
+	"news":      true,
+	"series":    true,
+	"sheep":     true,
+	"species":   true,
+	"fish":      true,
+	"data":      true,
+	"metadata":  true,
+}
+
+// TableName returns the table name for a struct type name, e.g. Category -> categories.
+func (ns *NamingStrategy) TableName(structName string) string {
+	name := ToSnakeCase(structName)
+	prefix := ""
+	if ns != nil {
+		prefix = ns.TablePrefix
+		if ns.SingularTable {
+			return prefix + name
+		}
+	}
+	return prefix + ns.pluralize(name)
+}
+
+// ColumnName returns the column name for a struct field name, e.g. UserID -> user_id.
+func (ns *NamingStrategy) ColumnName(field string) string {
+	if ns != nil && ns.ColumnMapper != nil {
+		return ns.ColumnMapper(field)
+	}
+	return ToSnakeCase(field)
+}
+
+// pluralize pluralizes the last word of a snake_case name.
+func (ns *NamingStrategy) pluralize(name string) string {
+	head, word := "", name
+	if i := strings.LastIndex(name, "_"); i >= 0 {
+		head, word = name[:i+1], name[i+1:]
+	}
+
+	if ns != nil {
+		if plural, ok := ns.Irregular[word]; ok {
+			return head + plural
+		}
+	}
+	if plural, ok := irregularPlurals[word]; ok {
+		return head + plural
+	}
+	if uncountable[word] {
+		return name
+	}
+
+	switch {
+	case strings.HasSuffix(word, "y") && len(word) > 1 && !strings.ContainsRune("aeiou", rune(word[len(word)-2])):
+		word = word[:len(word)-1] + "ies"
+	case strings.HasSuffix(word, "s"), strings.HasSuffix(word, "x"), strings.HasSuffix(word, "z"),
+		strings.HasSuffix(word, "ch"), strings.HasSuffix(word, "sh"):
+		word += "es"
+	default:
+		word += "s"
+	}
+	return head + word
+}
+
+// ToSnakeCase converts CamelCase or PascalCase to snake_case.
+// Acronyms are kept together: UserID -> user_id, HTTPServer -> http_server.
+func ToSnakeCase(s string) string {
+	rs := []rune(s)
+	var result []rune
+	for i, r := range rs {
+		if i > 0 && isUpper(r) {
+			prev := rs[i-1]
+			nextLower := i+1 < len(rs) && !isUpper(rs[i+1]) && rs[i+1] != '_'
+			if (!isUpper(prev) && prev != '_') || (isUpper(prev) && nextLower) {
+				result = append(result, '_')
+			}
+		}
+		result = append(result, r)
+	}
+	return stringLower(result)
+}
+
+func isUpper(r rune) bool {
+	return r >= 'A' && r <= 'Z'
+}
+
+func stringLower(rs []rune) string {
+	for i := range rs {
+		if rs[i] >= 'A' && rs[i] <= 'Z' {
+			rs[i] += 'a' - 'A'
+		}
+	}
+	return string(rs)
+}
@@ -0,0 +1,95 @@
+package model_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/adipras/torm/model"
+)
+
+func TestNamingStrategyTableName(t *testing.T) {
+	var defaults *model.NamingStrategy
+	cases := map[string]string{
+		"User":         "users",
+		"Category":     "categories",
+		"Person":       "people",
+		"Address":      "addresses",
+		"Day":          "days",
+		"OrderItem":    "order_items",
+		"SalesPerson":  "sales_people",
+		"HTTPServer":   "http_servers",
+		"Sheep":        "sheep",
+		"UserMetadata": "user_metadata",
+	}
+	for name, want := range cases {
+		if got := defaults.TableName(name); got != want {
+			t.Errorf("TableName(%q) = %s, want %s", name, got, want)
+		}
+	}
+
+	ns := &model.NamingStrategy{TablePrefix: "app_", Irregular: map[string]string{"cactus": "cacti"}}
+	if got := ns.TableName("Cactus"); got != "app_cacti" {
+		t.Errorf("TableName(Cactus) = %s, want app_cacti", got)
+	}
+
+	ns = &model.NamingStrategy{TablePrefix: "app_", SingularTable: true}
+	if got := ns.TableName("Category"); got != "app_category" {
+		t.Errorf("TableName(Category) = %s, want app_category", got)
+	}
+}
+
+func TestNamingStrategyColumnName(t *testing.T) {
+	var defaults *model.NamingStrategy
+	cases := map[string]string{
+		"Name":       "name",
+		"ID":         "id",
+		"UserID":     "user_id",
+		"HTTPServer": "http_server",
+		"APIKeyID":   "api_key_id",
+		"Address2":   "address2",
+	}
+	for name, want := range cases {
+		if got := defaults.ColumnName(name); got != want {
+			t.Errorf("ColumnName(%q) = %s, want %s", name, got, want)
+		}
+	}
+
+	ns := &model.NamingStrategy{ColumnMapper: strings.ToUpper}
+	if got := ns.ColumnName("UserID"); got != "USERID" {
+		t.Errorf("ColumnName with mapper = %s, want USERID", got)
+	}
+}
+
+type Invoice struct {
+	ID       int
+	ClientID int
+	Memo     string `db:"note"`
+}
+
+type LegacyInvoice struct {
+	ID int
+}
+
+func (LegacyInvoice) TableName() string { return "tbl_invoice" }
+
+func TestParseWithNaming(t *testing.T) {
+	ns := &model.NamingStrategy{TablePrefix: "app_", ColumnMapper: strings.ToUpper}
+	schema := model.ParseWith(&Invoice{}, ns)
+
+	if schema.Table() != "app_invoices" {
+		t.Errorf("table = %s, want app_invoices", schema.Table())
+	}
+	if f, ok := schema.LookUpField("ClientID"); !ok || f.Column() != "CLIENTID" {
+		t.Errorf("ClientID column = %s, want CLIENTID", f.Column())
+	}
+	if f, _ := schema.LookUpField("Memo"); f.Column() != "note" {
+		t.Errorf("tagged column = %s, want note", f.Column())
+	}
+
+	if got := model.Parse(&Invoice{}).Table(); got != "invoices" {
+		t.Errorf("default table = %s, want invoices", got)
+	}
+	if got := model.ParseWith(LegacyInvoice{}, ns).Table(); got != "tbl_invoice" {
+		t.Errorf("TableName() override = %s, want tbl_invoice", got)
+	}
+}
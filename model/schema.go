@@ -1,74 +1,491 @@
 package model
 
 import (
+	"database/sql"
 	"fmt"
 	"reflect"
+	"strconv"
+	"strings"
 	"sync"
-
-	"github.com/adipras/torm/utils"
+	"time"
 )
 
 type Field struct {
-	Name   string // struct field name (e.g. "UserName")
-	DBName string // db column name (e.g. "user_name")
+	Name       string       // struct field name (e.g. "UserName", "Address.Street" inside a named embedded struct)
+	DBName     string       // db column name (e.g. "user_name")
+	Type       string       // SQL column type (tag option "type:varchar(64)"), empty to derive it
+	Size       int          // column size (tag option "size:255")
+	Nullable   bool         // column accepts NULL; false with tag option "notnull" or "pk"
+	Default    string       // default value expression (tag option "default:'x'")
+	HasDefault bool         // column has a database-generated default (tag option "default", "default:..." or "autoincr")
+	PK         bool         // column is (part of) the primary key (tag option "pk")
+	AutoIncr   bool         // column is auto-incremented by the database (tag option "autoincr")
+	Unique     bool         // column has a unique constraint (tag option "unique")
+	Indexes    []string     // names of the indexes covering the column (tag option "index" or "index:name")
+	ReadOnly   bool         // column is never written by torm (tag option "readonly")
+	Index      []int        // index path of the field within the model struct
+	GoType     reflect.Type // Go type of the struct field
+
+	// AutoCreateTime and AutoUpdateTime mark timestamps set on insert and on
+	// every update. CreatedAt and UpdatedAt fields of type time.Time,
+	// *time.Time or an integer (unix seconds) are detected by name; other
+	// fields opt in with the tag options "autoCreateTime" and "autoUpdateTime",
+	// and ":false" opts out.
+	AutoCreateTime bool
+	AutoUpdateTime bool
+
+	// SoftDelete marks the timestamp set instead of deleting the row, which is
+	// NULL while the row is live. A DeletedAt field of type *time.Time or
+	// sql.NullTime is detected by name; others opt in with the tag option "softDelete".
+	SoftDelete bool
+
+	// Version marks an integer column used for optimistic locking
+	// (tag option "version"): updates check and increment it.
+	Version bool
 }
 
 func (f Field) Column() string {
 	return f.DBName
 }
 
+// ValueOf returns the field's value within v, a model struct value.
+// A nil embedded struct pointer yields the zero value of the field.
+func (f Field) ValueOf(v reflect.Value) reflect.Value {
+	for k, i := range f.Index {
+		if v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				return reflect.Zero(v.Type().Elem().FieldByIndex(f.Index[k:]).Type)
+			}
+			v = v.Elem()
+		}
+		v = v.Field(i)
+	}
+	return v
+}
+
+// Target returns the field within v for assignment, allocating nil embedded
+// struct pointers on the way. v must be addressable.
+func (f Field) Target(v reflect.Value) reflect.Value {
+	for _, i := range f.Index {
+		if v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(i)
+	}
+	return v
+}
+
 type Schema struct {
-	TableName string
-	Fields    []Field
+	TableName     string
+	Fields        []Field
+	PrimaryFields []Field // fields tagged "pk", or the ID field when none are
+
+	typ        reflect.Type
+	naming     *NamingStrategy
+	nested     []nestedField // struct fields whose columns are scanned with a prefix
+	scanOnce   sync.Once
+	scanFields map[string]ScanField
+}
+
+// nestedField is a nested or embedded struct field of a model. Rows fill its
+// columns from "<col>__<column>" or "<col>.<column>", as selected by joins.
+type nestedField struct {
+	name  string
+	col   string
+	index []int
+	typ   reflect.Type
 }
 
 func (s *Schema) Table() string {
 	return s.TableName
 }
 
+// LookUpField finds a field by its Go field name or its column name.
+func (s *Schema) LookUpField(name string) (Field, bool) {
+	for _, f := range s.Fields {
+		if f.Name == name || f.DBName == name {
+			return f, true
+		}
+	}
+	return Field{}, false
+}
+
+// DeletedAtField returns the soft-delete field, if the model has one.
+func (s *Schema) DeletedAtField() (Field, bool) {
+	for _, f := range s.Fields {
+		if f.SoftDelete {
+			return f, true
+		}
+	}
+	return Field{}, false
+}
+
+// VersionField returns the optimistic locking field, if the model has one.
+func (s *Schema) VersionField() (Field, bool) {
+	for _, f := range s.Fields {
+		if f.Version {
+			return f, true
+		}
+	}
+	return Field{}, false
+}
+
+// PrimaryField returns the primary key field.
+// It reports false if the model has no primary key or a composite one.
+func (s *Schema) PrimaryField() (Field, bool) {
+	if len(s.PrimaryFields) != 1 {
+		return Field{}, false
+	}
+	return s.PrimaryFields[0], true
+}
+
+// ScanField is a field a result column is scanned into.
+type ScanField struct {
+	Field
+	Nested bool // column of a nested or embedded struct field, NULL when a LEFT JOIN matched no row
+}
+
+// ScanFields returns the fields a row is scanned into, by column name: the
+// schema's columns, plus the columns of nested and embedded struct fields as
+// "<field>__<column>" and "<field>.<column>". Their Index is the full path
+// from the model struct. The shallowest field of a column wins, then the first.
+func (s *Schema) ScanFields() map[string]ScanField {
+	s.scanOnce.Do(func() {
+		s.scanFields = s.collectScanFields(map[reflect.Type]bool{})
+	})
+	return s.scanFields
+}
+
+// collectScanFields builds ScanFields; visiting guards against
+// self-referencing types such as a Parent *Node field.
+func (s *Schema) collectScanFields(visiting map[reflect.Type]bool) map[string]ScanField {
+	visiting[s.typ] = true
+	defer delete(visiting, s.typ)
+
+	fields := map[string]ScanField{}
+	set := func(col string, f ScanField) {
+		if existing, ok := fields[col]; !ok || len(f.Index) < len(existing.Index) {
+			fields[col] = f
+		}
+	}
+
+	for _, f := range s.Fields {
+		if !isNestedStruct(f.GoType) {
+			set(f.DBName, ScanField{Field: f})
+		}
+	}
+	for _, n := range s.nested {
+		if visiting[n.typ] {
+			continue
+		}
+		sub := ParseWith(reflect.New(n.typ).Interface(), s.naming)
+		for col, f := range sub.collectScanFields(visiting) {
+			f.Name = n.name + "." + f.Name
+			f.Index = append(append([]int{}, n.index...), f.Index...)
+			f.Nested = true
+			set(n.col+"__"+col, f)
+			set(n.col+"."+col, f)
+		}
+	}
+	return fields
+}
+
+// schemaKey identifies a cached schema: the same type parses differently
+// under different naming strategies.
+type schemaKey struct {
+	t      reflect.Type
+	naming *NamingStrategy
+}
+
 var schemaCache = sync.Map{}
 
-// Parse parses a struct into a Schema definition (with caching).
+// Parse parses a struct into a Schema definition (with caching)
+// using the default naming strategy.
 func Parse(model any) *Schema {
+	return ParseWith(model, nil)
+}
+
+// ParseWith is like Parse but derives table and column names with naming.
+// A TableName method on the model and explicit db tags take precedence.
+func ParseWith(model any, naming *NamingStrategy) *Schema {
 	rt := reflect.TypeOf(model)
 	if rt.Kind() == reflect.Ptr {
 		rt = rt.Elem()
 	}
 
-	if cached, ok := schemaCache.Load(rt); ok {
+	key := schemaKey{rt, naming}
+	if cached, ok := schemaCache.Load(key); ok {
 		return cached.(*Schema)
 	}
 
 	schema := &Schema{
-		TableName: parseTableName(rt.Name()),
+		TableName: naming.TableName(rt.Name()),
+		typ:       rt,
+		naming:    naming,
+	}
+	if tabler, ok := reflect.New(rt).Interface().(Tabler); ok {
+		schema.TableName = tabler.TableName()
 	}
 
-	for i := 0; i < rt.NumField(); i++ {
-		field := rt.Field(i)
+	schema.Fields = parseFields(rt, nil, "", "", schema.TableName, naming, &schema.nested, map[reflect.Type]bool{})
 
-		// Skip unexported fields
-		if field.PkgPath != "" {
+	// Without explicit pk tags, a field named ID is the primary key
+	hasPK := false
+	for _, f := range schema.Fields {
+		hasPK = hasPK || f.PK
+	}
+	for i := range schema.Fields {
+		if !hasPK && schema.Fields[i].Name == "ID" {
+			schema.Fields[i].PK = true
+			schema.Fields[i].Nullable = false
+		}
+		if schema.Fields[i].PK {
+			schema.PrimaryFields = append(schema.PrimaryFields, schema.Fields[i])
+		}
+	}
+
+	schemaCache.Store(key, schema)
+	return schema
+}
+
+// parseFields parses the fields of struct type t, flattening anonymous
+// embedded structs and named struct fields tagged "embedded". A named field's
+// columns get the tag's "prefix:" (e.g. `db:"embedded,prefix:addr_"`) and its
+// fields are named "<Field>.<SubField>". Like Go's field promotion, a field
+// declared at a shallower depth hides embedded fields with the same name.
+// Exported nested and embedded struct fields are recorded in nested.
+func parseFields(t reflect.Type, index []int, namePrefix, colPrefix, table string, naming *NamingStrategy, nested *[]nestedField, visiting map[reflect.Type]bool) []Field {
+	visiting[t] = true
+	defer delete(visiting, t)
+
+	type candidate struct {
+		field Field
+		depth int
+	}
+	candidates := []candidate{}
+	add := func(f Field) {
+		f.DBName = colPrefix + f.DBName
+		candidates = append(candidates, candidate{f, len(f.Index)})
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("db")
+		if tag == "-" {
+			continue
+		}
+
+		path := append(append([]int{}, index...), i)
+		opts := splitTag(tag)
+		embedded, prefix := field.Anonymous, ""
+		// "embedded" may also stand in the column name position
+		for _, opt := range opts {
+			key, val, _ := strings.Cut(opt, ":")
+			switch strings.TrimSpace(key) {
+			case "embedded":
+				embedded = true
+			case "prefix":
+				prefix = strings.TrimSpace(val)
+			}
+		}
+
+		// Exported fields of an embedded unexported struct are still promoted
+		if !field.IsExported() && !(field.Anonymous && field.Type.Kind() == reflect.Struct) {
+			continue
+		}
+
+		ft := field.Type
+		if ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+
+		if embedded && isNestedStruct(field.Type) {
+			if visiting[ft] {
+				continue
+			}
+			names := namePrefix
+			if !field.Anonymous {
+				names += field.Name + "."
+			}
+			for _, f := range parseFields(ft, path, names, prefix, table, naming, nested, visiting) {
+				add(f)
+			}
+			if field.IsExported() {
+				col := opts[0]
+				if col == "" || col == "embedded" {
+					col = naming.ColumnName(field.Name)
+				}
+				*nested = append(*nested, nestedField{namePrefix + field.Name, colPrefix + col, path, ft})
+			}
 			continue
 		}
 
-		dbTag := field.Tag.Get("db")
-		if dbTag == "-" {
+		if !field.IsExported() {
 			continue
 		}
 
-		column := dbTag
-		if column == "" {
-			column = utils.ToSnakeCase(field.Name)
+		f := parseField(field, table, naming)
+		f.Name = namePrefix + f.Name
+		f.Index = path
+		if isNestedStruct(field.Type) {
+			*nested = append(*nested, nestedField{f.Name, colPrefix + f.DBName, path, ft})
+		}
+		add(f)
+	}
+
+	// Keep the shallowest field of each name, in declaration order
+	shallowest := map[string]int{}
+	for _, c := range candidates {
+		if d, ok := shallowest[c.field.Name]; !ok || c.depth < d {
+			shallowest[c.field.Name] = c.depth
+		}
+	}
+	fields := []Field{}
+	for _, c := range candidates {
+		if shallowest[c.field.Name] == c.depth {
+			fields = append(fields, c.field)
+			shallowest[c.field.Name] = -1 // first one wins on ties
 		}
+	}
+	return fields
+}
+
+// parseField reads the field's column name and options from its db tag, e.g.
+// `db:"email,unique,size:255,notnull,default:'x',readonly,autoincr,index:idx_email"`.
+// Unknown options are ignored.
+func parseField(field reflect.StructField, table string, naming *NamingStrategy) Field {
+	opts := splitTag(field.Tag.Get("db"))
 
-		schema.Fields = append(schema.Fields, Field{
-			Name:   field.Name,
-			DBName: column,
-		})
+	f := Field{
+		Name:     field.Name,
+		DBName:   opts[0],
+		Nullable: true,
+		GoType:   field.Type,
 	}
+	if f.DBName == "" {
+		f.DBName = naming.ColumnName(field.Name)
+	}
+	if isTimestampType(field.Type) {
+		f.AutoCreateTime = field.Name == "CreatedAt"
+		f.AutoUpdateTime = field.Name == "UpdatedAt"
+	}
+	f.SoftDelete = field.Name == "DeletedAt" && isNullTimeType(field.Type)
 
-	schemaCache.Store(rt, schema)
-	return schema
+	for _, opt := range opts[1:] {
+		key, val, hasVal := strings.Cut(opt, ":")
+		switch strings.ToLower(strings.TrimSpace(key)) {
+		case "type":
+			f.Type = strings.TrimSpace(val)
+		case "size":
+			f.Size, _ = strconv.Atoi(strings.TrimSpace(val))
+		case "null":
+			f.Nullable = true
+		case "notnull":
+			f.Nullable = false
+		case "default":
+			f.HasDefault = true
+			if hasVal {
+				f.Default = strings.TrimSpace(val)
+			}
+		case "pk":
+			f.PK = true
+			f.Nullable = false
+		case "autoincr":
+			f.AutoIncr = true
+			f.HasDefault = true
+		case "unique":
+			f.Unique = true
+		case "index":
+			name := strings.TrimSpace(val)
+			if name == "" {
+				name = "idx_" + table + "_" + f.DBName
+			}
+			f.Indexes = append(f.Indexes, name)
+		case "readonly":
+			f.ReadOnly = true
+		case "autocreatetime":
+			f.AutoCreateTime = isTimestampType(field.Type) && strings.TrimSpace(val) != "false"
+		case "autoupdatetime":
+			f.AutoUpdateTime = isTimestampType(field.Type) && strings.TrimSpace(val) != "false"
+		case "version":
+			f.Version = isIntegerType(field.Type)
+		case "softdelete":
+			f.SoftDelete = isNullTimeType(field.Type) && strings.TrimSpace(val) != "false"
+		}
+	}
+
+	return f
+}
+
+var (
+	timeType     = reflect.TypeOf(time.Time{})
+	nullTimeType = reflect.TypeOf(sql.NullTime{})
+	scannerType  = reflect.TypeOf((*sql.Scanner)(nil)).Elem()
+)
+
+// isNestedStruct reports whether t is a struct (or pointer to struct) whose
+// fields map to columns, as opposed to a single-column value like time.Time.
+func isNestedStruct(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct || t == timeType {
+		return false
+	}
+	return !reflect.PointerTo(t).Implements(scannerType)
+}
+
+// isNullTimeType reports whether t can hold a soft-delete timestamp, which
+// must be able to represent NULL: *time.Time or sql.NullTime.
+func isNullTimeType(t reflect.Type) bool {
+	return t == reflect.PointerTo(timeType) || t == nullTimeType
+}
+
+// isTimestampType reports whether t can hold an automatic timestamp:
+// time.Time, *time.Time or an integer holding unix seconds.
+func isTimestampType(t reflect.Type) bool {
+	if t == timeType || t == reflect.PointerTo(timeType) {
+		return true
+	}
+	return isIntegerType(t)
+}
+
+// isIntegerType reports whether t is an integer type wide enough for
+// unix timestamps and version counters.
+func isIntegerType(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Int, reflect.Int32, reflect.Int64, reflect.Uint, reflect.Uint32, reflect.Uint64:
+		return true
+	}
+	return false
+}
+
+// splitTag splits a db tag on commas that are outside quotes and parentheses,
+// so values like default:'a,b' and type:decimal(10,2) stay whole.
+func splitTag(tag string) []string {
+	parts := []string{}
+	depth, quote, start := 0, rune(0), 0
+	for i, r := range tag {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '\'' || r == '"':
+			quote = r
+		case r == '(':
+			depth++
+		case r == ')':
+			depth--
+		case r == ',' && depth == 0:
+			parts = append(parts, strings.TrimSpace(tag[start:i]))
+			start = i + 1
+		}
+	}
+	return append(parts, strings.TrimSpace(tag[start:]))
 }
 
 func ExtractSchema(model any) (*Schema, error) {
@@ -78,7 +495,8 @@ func ExtractSchema(model any) (*Schema, error) {
 	return Parse(model), nil
 }
 
-// ExtractValues extracts struct field values as map[fieldName]any
+// ExtractValues extracts struct field values as map[fieldName]any,
+// keyed like Schema.Fields so embedded struct fields are flattened.
 func ExtractValues(model any) (map[string]any, error) {
 	result := map[string]any{}
 
@@ -90,23 +508,9 @@ func ExtractValues(model any) (map[string]any, error) {
 		return nil, fmt.Errorf("model must be struct or pointer to struct")
 	}
 
-	t := v.Type()
-	for i := 0; i < v.NumField(); i++ {
-		field := t.Field(i)
-
-		// Skip unexported fields
-		if field.PkgPath != "" {
-			continue
-		}
-
-		val := v.Field(i).Interface()
-		result[field.Name] = val
+	for _, f := range Parse(model).Fields {
+		result[f.Name] = f.ValueOf(v).Interface()
 	}
 
 	return result, nil
 }
-
-// Default table name = pluralized snake_case struct name (can be overridden later)
-func parseTableName(name string) string {
-	return utils.ToSnakeCase(name) + "s" // e.g. User -> users
-}
@@ -0,0 +1,223 @@
+package model_test
+
+import (
+	"database/sql"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/adipras/torm/model"
+)
+
+type Account struct {
+	ID      int     `db:"id,pk,autoincr"`
+	Email   string  `db:"email,unique,size:255,notnull,default:'a,b',index:idx_email"`
+	Balance float64 `db:"balance,type:decimal(10,2),index,index:idx_balance_email"`
+	Total   int     `db:"total,readonly"`
+	Note    *string
+	Skip    string `db:"-"`
+}
+
+func TestParseTagOptions(t *testing.T) {
+	schema := model.Parse(&Account{})
+
+	if len(schema.Fields) != 5 {
+		t.Fatalf("expected 5 fields, got %d", len(schema.Fields))
+	}
+
+	id, _ := schema.LookUpField("ID")
+	if !id.PK || !id.AutoIncr || !id.HasDefault || id.Nullable {
+		t.Errorf("unexpected id field: %+v", id)
+	}
+
+	email, _ := schema.LookUpField("email")
+	want := model.Field{
+		Name:       "Email",
+		DBName:     "email",
+		Size:       255,
+		Default:    "'a,b'",
+		HasDefault: true,
+		Unique:     true,
+		Indexes:    []string{"idx_email"},
+		Index:      []int{1},
+		GoType:     reflect.TypeOf(""),
+	}
+	if !reflect.DeepEqual(email, want) {
+		t.Errorf("email field = %+v, want %+v", email, want)
+	}
+
+	balance, _ := schema.LookUpField("Balance")
+	if balance.Type != "decimal(10,2)" || !balance.Nullable {
+		t.Errorf("unexpected balance field: %+v", balance)
+	}
+	if want := []string{"idx_accounts_balance", "idx_balance_email"}; !reflect.DeepEqual(balance.Indexes, want) {
+		t.Errorf("balance indexes = %v, want %v", balance.Indexes, want)
+	}
+
+	if total, _ := schema.LookUpField("total"); !total.ReadOnly {
+		t.Errorf("expected total to be read-only")
+	}
+
+	if note, ok := schema.LookUpField("note"); !ok || !note.Nullable {
+		t.Errorf("expected nullable note column, got %+v", note)
+	}
+}
+
+type Base struct {
+	ID        int
+	CreatedAt int64
+}
+
+type Address struct {
+	Street string
+	City   string `db:"town"`
+}
+
+type Customer struct {
+	Base
+	Name    string
+	Home    Address  `db:"embedded,prefix:home_"`
+	Work    *Address `db:"embedded,prefix:work_"`
+	Billing Address  `db:"billing"`
+}
+
+func TestParseEmbedded(t *testing.T) {
+	schema := model.Parse(&Customer{})
+
+	cols := []string{}
+	for _, f := range schema.Fields {
+		cols = append(cols, f.Name+":"+f.Column())
+	}
+	want := []string{
+		"ID:id", "CreatedAt:created_at", "Name:name",
+		"Home.Street:home_street", "Home.City:home_town",
+		"Work.Street:work_street", "Work.City:work_town",
+		"Billing:billing",
+	}
+	if !reflect.DeepEqual(cols, want) {
+		t.Errorf("fields = %v, want %v", cols, want)
+	}
+
+	if pk, ok := schema.PrimaryField(); !ok || pk.Name != "ID" {
+		t.Errorf("expected promoted ID primary key, got %+v", pk)
+	}
+
+	c := Customer{Base: Base{ID: 7}, Home: Address{Street: "Jl. Merdeka"}}
+	vals, err := model.ExtractValues(&c)
+	if err != nil {
+		t.Fatalf("ExtractValues() failed: %v", err)
+	}
+	if vals["ID"] != 7 || vals["Home.Street"] != "Jl. Merdeka" || vals["Work.City"] != "" {
+		t.Errorf("unexpected values: %v", vals)
+	}
+
+	street, _ := schema.LookUpField("work_street")
+	street.Target(reflect.ValueOf(&c).Elem()).SetString("Jl. Sudirman")
+	if c.Work == nil || c.Work.Street != "Jl. Sudirman" {
+		t.Errorf("expected Target to allocate Work, got %+v", c.Work)
+	}
+}
+
+func TestScanFields(t *testing.T) {
+	fields := model.Parse(&Customer{}).ScanFields()
+
+	want := map[string][]int{
+		"id":            {0, 0},
+		"home_town":     {2, 1},
+		"billing__town": {4, 1},
+		"billing.town":  {4, 1},
+		"work__street":  {3, 0},
+		"base__id":      {0, 0},
+	}
+	for col, index := range want {
+		if f, ok := fields[col]; !ok || !reflect.DeepEqual(f.Index, index) {
+			t.Errorf("ScanFields()[%q] = %v, want index %v", col, f.Index, index)
+		}
+	}
+	if fields["home_town"].Nested || !fields["billing__town"].Nested {
+		t.Error("expected only prefixed columns to be marked Nested")
+	}
+	if _, ok := fields["billing"]; ok {
+		t.Error("nested struct field billing should not be scanned as a column")
+	}
+
+	var c Customer
+	fields["work_town"].Target(reflect.ValueOf(&c).Elem()).SetString("Bandung")
+	if c.Work == nil || c.Work.City != "Bandung" {
+		t.Errorf("expected Work to be allocated and set, got %+v", c.Work)
+	}
+}
+
+type Stamped struct {
+	ID        int
+	CreatedAt time.Time
+	UpdatedAt *time.Time
+	DeletedAt time.Time
+	Touched   int64  `db:"touched,autoUpdateTime"`
+	Label     string `db:"label,autoCreateTime"`
+}
+
+type Unstamped struct {
+	CreatedAt int64 `db:"created_at,autoCreateTime:false"`
+	UpdatedAt string
+}
+
+func TestParseTimestamps(t *testing.T) {
+	auto := []string{}
+	for _, f := range model.Parse(&Stamped{}).Fields {
+		if f.AutoCreateTime {
+			auto = append(auto, "create:"+f.Name)
+		}
+		if f.AutoUpdateTime {
+			auto = append(auto, "update:"+f.Name)
+		}
+	}
+	if want := []string{"create:CreatedAt", "update:UpdatedAt", "update:Touched"}; !reflect.DeepEqual(auto, want) {
+		t.Errorf("auto timestamps = %v, want %v", auto, want)
+	}
+
+	for _, f := range model.Parse(&Unstamped{}).Fields {
+		if f.AutoCreateTime || f.AutoUpdateTime {
+			t.Errorf("expected %s not to be an automatic timestamp", f.Name)
+		}
+	}
+}
+
+type Archived struct {
+	ID        int
+	DeletedAt sql.NullTime
+}
+
+type Removed struct {
+	ID        int
+	RemovedAt *time.Time `db:"removed_at,softDelete"`
+}
+
+func TestParseSoftDelete(t *testing.T) {
+	if f, ok := model.Parse(&Archived{}).DeletedAtField(); !ok || f.Column() != "deleted_at" {
+		t.Errorf("expected deleted_at soft-delete field, got %+v", f)
+	}
+	if f, ok := model.Parse(&Removed{}).DeletedAtField(); !ok || f.Column() != "removed_at" {
+		t.Errorf("expected removed_at soft-delete field, got %+v", f)
+	}
+	// A non-nullable time cannot mark live rows with NULL
+	if _, ok := model.Parse(&Stamped{}).DeletedAtField(); ok {
+		t.Errorf("expected time.Time DeletedAt not to enable soft delete")
+	}
+}
+
+type Versioned struct {
+	ID    int
+	Rev   int64  `db:"rev,version"`
+	Label string `db:"label,version"`
+}
+
+func TestParseVersion(t *testing.T) {
+	f, ok := model.Parse(&Versioned{}).VersionField()
+	if !ok || f.Column() != "rev" {
+		t.Errorf("expected rev version field, got %+v", f)
+	}
+	if label, _ := model.Parse(&Versioned{}).LookUpField("label"); label.Version {
+		t.Errorf("expected non-integer field not to be a version")
+	}
+}
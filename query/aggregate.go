@@ -0,0 +1,223 @@
+package query
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/adipras/torm/dialect"
+)
+
+// Count returns the number of rows matching the builder's conditions.
+// Order, Limit and Offset are ignored. With Distinct, it counts the
+// distinct values of the selected columns, or the distinct rows.
+// With Group, it counts the groups.
+func (b *Builder) Count() (int64, error) {
+	return b.CountContext(b.context())
+}
+
+// CountContext is like Count but runs the query with the provided context.
+func (b *Builder) CountContext(ctx context.Context) (int64, error) {
+	if b.err != nil {
+		return 0, b.err
+	}
+
+	var n int64
+	err := b.queryRow(ctx, b.countQuery(), &n)
+	return n, err
+}
+
+// countQuery renders the statement run by Count.
+func (b *Builder) countQuery() string {
+	var sb strings.Builder
+	switch {
+	case len(b.groupCols) > 0:
+		sb.WriteString("SELECT COUNT(*) FROM (SELECT 1 AS one")
+		b.writeFrom(&sb)
+		sb.WriteString(") grouped")
+	case b.distinct && len(b.selectCols) != 1:
+		// COUNT(DISTINCT a, b) only exists in MySQL, and there is no COUNT(DISTINCT *)
+		sb.WriteString("SELECT COUNT(*) FROM (SELECT DISTINCT ")
+		sb.WriteString(b.columns())
+		b.writeFrom(&sb)
+		sb.WriteString(") distinct_rows")
+	case b.distinct && len(b.selectCols) == 1:
+		sb.WriteString("SELECT COUNT(DISTINCT " + b.columnList(b.selectCols) + ")")
+		b.writeFrom(&sb)
+	default:
+		sb.WriteString("SELECT COUNT(*)")
+		b.writeFrom(&sb)
+	}
+	return dialect.Rebind(b.db.Dialect, sb.String())
+}
+
+// Sum scans the sum of col over the matching rows into dest, a pointer such
+// as *int64 or *float64. dest is left unchanged if there are no matching rows.
+func (b *Builder) Sum(col string, dest any) error {
+	return b.SumContext(b.context(), col, dest)
+}
+
+// SumContext is like Sum but runs the query with the provided context.
+func (b *Builder) SumContext(ctx context.Context, col string, dest any) error {
+	return b.aggregateInto(ctx, "SUM", col, dest)
+}
+
+// Avg returns the average of col over the matching rows, or 0 if there are none.
+func (b *Builder) Avg(col string) (float64, error) {
+	return b.AvgContext(b.context(), col)
+}
+
+// AvgContext is like Avg but runs the query with the provided context.
+func (b *Builder) AvgContext(ctx context.Context, col string) (float64, error) {
+	var v sql.NullFloat64
+	if err := b.scanAggregate(ctx, "AVG("+b.column(col)+")", &v); err != nil {
+		return 0, err
+	}
+	return v.Float64, nil
+}
+
+// Min scans the smallest value of col over the matching rows into dest, a
+// pointer such as *int, *string or *time.Time. dest is left unchanged if
+// there are no matching rows.
+func (b *Builder) Min(col string, dest any) error {
+	return b.MinContext(b.context(), col, dest)
+}
+
+// MinContext is like Min but runs the query with the provided context.
+func (b *Builder) MinContext(ctx context.Context, col string, dest any) error {
+	return b.aggregateInto(ctx, "MIN", col, dest)
+}
+
+// Max scans the largest value of col over the matching rows into dest, like Min.
+func (b *Builder) Max(col string, dest any) error {
+	return b.MaxContext(b.context(), col, dest)
+}
+
+// MaxContext is like Max but runs the query with the provided context.
+func (b *Builder) MaxContext(ctx context.Context, col string, dest any) error {
+	return b.aggregateInto(ctx, "MAX", col, dest)
+}
+
+// Exists reports whether at least one row matches the builder's conditions.
+func (b *Builder) Exists() (bool, error) {
+	return b.ExistsContext(b.context())
+}
+
+// ExistsContext is like Exists but runs the query with the provided context.
+func (b *Builder) ExistsContext(ctx context.Context) (bool, error) {
+	if b.err != nil {
+		return false, b.err
+	}
+
+	var sb strings.Builder
+	sb.WriteString("SELECT 1")
+	b.writeFrom(&sb)
+	sb.WriteString(" ")
+	sb.WriteString(b.db.Dialect.LimitOffset(1, -1))
+	query := dialect.Rebind(b.db.Dialect, sb.String())
+
+	ctx, cancel := b.db.WithTimeout(ctx)
+	defer cancel()
+
+	rows, err := b.db.Conn().QueryContext(ctx, query, b.queryArgs()...)
+	if err != nil {
+		return false, fmt.Errorf("query failed: %w", err)
+	}
+	defer rows.Close()
+
+	found := rows.Next()
+	return found, rows.Err()
+}
+
+// Pluck scans a single column of the matching rows into dest,
+// which must be a pointer to a slice of a scannable type (e.g. *[]string).
+func (b *Builder) Pluck(col string, dest any) error {
+	return b.PluckContext(b.context(), col, dest)
+}
+
+// PluckContext is like Pluck but runs the query with the provided context.
+func (b *Builder) PluckContext(ctx context.Context, col string, dest any) error {
+	destVal := reflect.ValueOf(dest)
+	if destVal.Kind() != reflect.Ptr || destVal.Elem().Kind() != reflect.Slice {
+		return errors.New("dest must be a pointer to slice")
+	}
+
+	column := b.column(col)
+	if b.err != nil {
+		return b.err
+	}
+
+	query := b.buildSelect(column, -1)
+
+	ctx, cancel := b.db.WithTimeout(ctx)
+	defer cancel()
+
+	rows, err := b.db.Conn().QueryContext(ctx, query, b.queryArgs()...)
+	if err != nil {
+		return fmt.Errorf("query failed: %w", err)
+	}
+	defer rows.Close()
+
+	sliceVal := destVal.Elem()
+	elemType := sliceVal.Type().Elem()
+	for rows.Next() {
+		elemPtr := reflect.New(elemType)
+		if err := rows.Scan(elemPtr.Interface()); err != nil {
+			return fmt.Errorf("failed to scan row: %w", err)
+		}
+		sliceVal.Set(reflect.Append(sliceVal, elemPtr.Elem()))
+	}
+
+	return rows.Err()
+}
+
+// aggregateInto runs fn(col) over the matching rows and scans the result
+// into dest, leaving dest unchanged when the result is NULL.
+func (b *Builder) aggregateInto(ctx context.Context, fn string, col string, dest any) error {
+	destVal := reflect.ValueOf(dest)
+	if destVal.Kind() != reflect.Ptr || destVal.IsNil() {
+		return errors.New("dest must be a non-nil pointer")
+	}
+
+	// Scanning through a pointer turns NULL into nil instead of an error
+	holder := reflect.New(destVal.Type())
+	if err := b.scanAggregate(ctx, fn+"("+b.column(col)+")", holder.Interface()); err != nil {
+		return err
+	}
+	if !holder.Elem().IsNil() {
+		destVal.Elem().Set(holder.Elem().Elem())
+	}
+	return nil
+}
+
+// scanAggregate runs SELECT expr FROM ... WHERE ... and scans the single result into dest.
+func (b *Builder) scanAggregate(ctx context.Context, expr string, dest any) error {
+	if b.err != nil {
+		return b.err
+	}
+
+	var sb strings.Builder
+	sb.WriteString("SELECT ")
+	sb.WriteString(expr)
+	b.writeFrom(&sb)
+
+	return b.queryRow(ctx, dialect.Rebind(b.db.Dialect, sb.String()), dest)
+}
+
+// queryRow runs a single-row query with the builder's bind values and scans it into dest.
+func (b *Builder) queryRow(ctx context.Context, query string, dest any) error {
+	if b.err != nil {
+		return b.err
+	}
+
+	ctx, cancel := b.db.WithTimeout(ctx)
+	defer cancel()
+
+	if err := b.db.Conn().QueryRowContext(ctx, query, b.queryArgs()...).Scan(dest); err != nil {
+		return fmt.Errorf("query failed: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,41 @@
+package query
+
+import (
+	"testing"
+
+	"github.com/adipras/torm/db"
+	"github.com/adipras/torm/dialect"
+)
+
+type player struct {
+	ID   int    `db:"id"`
+	Name string `db:"name"`
+	Club string `db:"club"`
+}
+
+func TestCountDistinctColumns(t *testing.T) {
+	cases := []struct {
+		d    dialect.Dialect
+		want string
+	}{
+		{dialect.MySQL{}, "SELECT COUNT(*) FROM (SELECT DISTINCT `name`, `club` FROM `players` WHERE id > ?) distinct_rows"},
+		{dialect.Postgres{}, `SELECT COUNT(*) FROM (SELECT DISTINCT "name", "club" FROM "players" WHERE id > $1) distinct_rows`},
+		{dialect.SQLite{}, `SELECT COUNT(*) FROM (SELECT DISTINCT "name", "club" FROM "players" WHERE id > ?) distinct_rows`},
+	}
+	for _, c := range cases {
+		b := NewBuilder(&db.DB{Dialect: c.d}, &player{}).Where("id > ?", 1).Distinct("name", "club")
+		if got := b.countQuery(); got != c.want {
+			t.Errorf("%s: countQuery() = %s, want %s", c.d.Name(), got, c.want)
+		}
+	}
+
+	b := NewBuilder(&db.DB{Dialect: dialect.Postgres{}}, &player{}).Distinct("club")
+	if got, want := b.countQuery(), `SELECT COUNT(DISTINCT "club") FROM "players"`; got != want {
+		t.Errorf("single column countQuery() = %s, want %s", got, want)
+	}
+
+	b = NewBuilder(&db.DB{Dialect: dialect.Postgres{}}, &player{}).Distinct()
+	if got, want := b.countQuery(), `SELECT COUNT(*) FROM (SELECT DISTINCT * FROM "players") distinct_rows`; got != want {
+		t.Errorf("distinct rows countQuery() = %s, want %s", got, want)
+	}
+}
@@ -1,113 +1,792 @@
 package query
 
 import (
+	"context"
 	"fmt"
 	"reflect"
+	"regexp"
 	"strings"
 
+	"github.com/adipras/torm/clause"
 	"github.com/adipras/torm/db"
+	"github.com/adipras/torm/dialect"
 	"github.com/adipras/torm/executor"
 	"github.com/adipras/torm/model"
 	"github.com/adipras/torm/utils"
 )
 
 type Builder struct {
-	db        *db.DB
-	modelRef  any
-	schema    *model.Schema
-	whereStmt []string
-	args      []any
+	db         *db.DB
+	ctx        context.Context
+	modelRef   any
+	schema     *model.Schema
+	whereStmt  []string
+	args       []any
+	orderStmt  []string
+	limit      int // -1 when unset
+	offset     int // -1 when unset
+	distinct   bool
+	selectCols []string
+	omitCols   []string
+	groupCols  []string
+	joinStmt   []string
+	joinArgs   []any
+	joinModels []joinedModel
+	havingStmt []string
+	havingArgs []any
+	onConflict *clause.OnConflict
+	err        error // first error from a chained call, returned by terminal methods
 }
 
+// joinedModel is a model joined via InnerJoin or LeftJoin whose columns
+// are selected as "<prefix>__<column>" for nested scanning.
+type joinedModel struct {
+	schema *model.Schema
+	prefix string
+}
+
+// identRe matches a bare column name, as opposed to an SQL expression.
+var identRe = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
 // NewBuilder creates a new query builder for the given model.
 func NewBuilder(d *db.DB, modelStruct any) *Builder {
-	schema := model.Parse(modelStruct)
+	schema := model.ParseWith(modelStruct, d.Naming)
 	return &Builder{
 		db:       d,
 		modelRef: modelStruct,
 		schema:   schema,
+		limit:    -1,
+		offset:   -1,
 	}
 }
 
+// WithContext sets the context used by the builder's terminal methods.
+func (b *Builder) WithContext(ctx context.Context) *Builder {
+	b.ctx = ctx
+	return b
+}
+
+func (b *Builder) context() context.Context {
+	if b.ctx == nil {
+		return context.Background()
+	}
+	return b.ctx
+}
+
 // Where adds a WHERE clause to the query.
+// An argument may be a clause.Expr, whose SQL replaces its placeholder.
 func (b *Builder) Where(condition string, args ...any) *Builder {
+	condition, args = clause.Expand(condition, args)
 	b.whereStmt = append(b.whereStmt, condition)
 	b.args = append(b.args, args...)
 	return b
 }
 
+// Joins adds a raw JOIN clause, e.g. Joins("JOIN orders ON orders.user_id = users.id").
+func (b *Builder) Joins(clause string, args ...any) *Builder {
+	b.joinStmt = append(b.joinStmt, clause)
+	b.joinArgs = append(b.joinArgs, args...)
+	return b
+}
+
+// InnerJoin joins the table of joinModel using the ON condition.
+// Unless Select is used, the joined model's columns are selected as
+// "<model>__<column>" (e.g. "order__total" for an Order model) so they
+// can be scanned into a nested struct field named after the model.
+func (b *Builder) InnerJoin(joinModel any, on string, args ...any) *Builder {
+	return b.join("INNER JOIN", joinModel, on, args)
+}
+
+// LeftJoin is like InnerJoin but uses LEFT JOIN.
+func (b *Builder) LeftJoin(joinModel any, on string, args ...any) *Builder {
+	return b.join("LEFT JOIN", joinModel, on, args)
+}
+
+func (b *Builder) join(kind string, joinModel any, on string, args []any) *Builder {
+	schema := model.ParseWith(joinModel, b.db.Naming)
+	b.joinModels = append(b.joinModels, joinedModel{
+		schema: schema,
+		prefix: b.db.Naming.ColumnName(reflect.Indirect(reflect.ValueOf(joinModel)).Type().Name()),
+	})
+	return b.Joins(kind+" "+b.db.Dialect.Quote(schema.TableName)+" ON "+on, args...)
+}
+
+// Unscoped makes the builder ignore soft deletes: queries include
+// soft-deleted rows and Delete removes rows permanently.
+func (b *Builder) Unscoped() *Builder {
+	conn := *b.db
+	conn.Unscoped = true
+	b.db = &conn
+	return b
+}
+
+// RequireAffected makes the builder's updates and deletes return
+// sql.ErrNoRows when they affect no rows.
+func (b *Builder) RequireAffected() *Builder {
+	conn := *b.db
+	conn.RequireAffected = true
+	b.db = &conn
+	return b
+}
+
+// AllowGlobalUpdate lets the builder's updates and deletes run without
+// Where conditions, affecting every row.
+func (b *Builder) AllowGlobalUpdate() *Builder {
+	conn := *b.db
+	conn.AllowGlobalUpdate = true
+	b.db = &conn
+	return b
+}
+
+// Group adds GROUP BY columns to the query.
+func (b *Builder) Group(cols ...string) *Builder {
+	for _, c := range cols {
+		b.validate(c)
+		b.groupCols = append(b.groupCols, c)
+	}
+	return b
+}
+
+// Having adds a HAVING condition, e.g. Having("COUNT(*) > ?", 1).
+func (b *Builder) Having(condition string, args ...any) *Builder {
+	condition, args = clause.Expand(condition, args)
+	b.havingStmt = append(b.havingStmt, condition)
+	b.havingArgs = append(b.havingArgs, args...)
+	return b
+}
+
+// Order adds an ORDER BY expression, e.g. Order("age DESC").
+// Multiple calls are combined in the order they were made.
+func (b *Builder) Order(value string) *Builder {
+	b.orderStmt = append(b.orderStmt, value)
+	return b
+}
+
+// Limit sets the maximum number of rows to return. A negative n removes the limit.
+func (b *Builder) Limit(n int) *Builder {
+	b.limit = n
+	return b
+}
+
+// Offset sets the number of rows to skip. A negative n removes the offset.
+func (b *Builder) Offset(n int) *Builder {
+	b.offset = n
+	return b
+}
+
+// Distinct makes the query SELECT DISTINCT, optionally over the given columns only.
+func (b *Builder) Distinct(cols ...string) *Builder {
+	b.distinct = true
+	if len(cols) > 0 {
+		b.Select(cols...)
+	}
+	return b
+}
+
+// Select restricts the query to the given columns.
+// Columns may be given as Go field names or column names and must belong to the model;
+// expressions such as "COUNT(*) AS total" are passed through as they are.
+// For Update and Updates with a struct, only the selected columns are written,
+// zero values included.
+func (b *Builder) Select(cols ...string) *Builder {
+	for _, c := range cols {
+		b.validate(c)
+		b.selectCols = append(b.selectCols, c)
+	}
+	return b
+}
+
+// Omit selects every model column except the given ones.
+// For Update and Updates with a struct, the given columns are not written.
+func (b *Builder) Omit(cols ...string) *Builder {
+	for _, c := range cols {
+		if f, ok := b.lookUpField(c); ok {
+			b.omitCols = append(b.omitCols, f.Column())
+		}
+	}
+	return b
+}
+
+// lookUpField resolves a field or column name against the model schema,
+// recording an error on the builder if it is unknown.
+func (b *Builder) lookUpField(name string) (model.Field, bool) {
+	f, ok := b.schema.LookUpField(name)
+	if !ok && b.err == nil {
+		b.err = fmt.Errorf("%w %q for table %s", executor.ErrUnknownColumn, name, b.schema.TableName)
+	}
+	return f, ok
+}
+
+// validate records an error if name is a bare identifier that is not a model column.
+func (b *Builder) validate(name string) {
+	if identRe.MatchString(name) {
+		b.lookUpField(name)
+	}
+}
+
+// column resolves name to a quoted column of the model.
+// Anything that is neither a bare identifier nor a field name such as
+// "Address.Street" is treated as an expression and returned as is.
+func (b *Builder) column(name string) string {
+	if f, ok := b.schema.LookUpField(name); ok {
+		return b.qualify(f.Column())
+	}
+	if !identRe.MatchString(name) {
+		return name
+	}
+	if f, ok := b.lookUpField(name); ok {
+		return b.qualify(f.Column())
+	}
+	return name
+}
+
+// qualify quotes a model column, prefixing the table name when the query has joins.
+func (b *Builder) qualify(col string) string {
+	if len(b.joinStmt) > 0 {
+		return b.db.Dialect.Quote(b.schema.TableName + "." + col)
+	}
+	return b.db.Dialect.Quote(col)
+}
+
+// columnList renders names as a comma-separated list of columns.
+func (b *Builder) columnList(names []string) string {
+	cols := make([]string, len(names))
+	for i, n := range names {
+		cols[i] = b.column(n)
+	}
+	return strings.Join(cols, ", ")
+}
+
+// columns renders the SELECT column list.
+func (b *Builder) columns() string {
+	if len(b.selectCols) > 0 {
+		return b.columnList(b.selectCols)
+	}
+	// With joins, a bare * would let the joined tables' columns (e.g. id)
+	// overwrite the model's own when scanning
+	if len(b.omitCols) == 0 && len(b.joinStmt) == 0 {
+		return "*"
+	}
+
+	d := b.db.Dialect
+	cols := []string{}
+	if len(b.omitCols) == 0 {
+		cols = append(cols, d.Quote(b.schema.TableName+".*"))
+	} else {
+		for _, f := range b.schema.Fields {
+			omitted := false
+			for _, o := range b.omitCols {
+				if f.Column() == o {
+					omitted = true
+					break
+				}
+			}
+			if !omitted {
+				cols = append(cols, b.qualify(f.Column()))
+			}
+		}
+	}
+
+	for _, jm := range b.joinModels {
+		for _, f := range jm.schema.Fields {
+			cols = append(cols, d.Quote(jm.schema.TableName+"."+f.Column())+" AS "+d.Quote(jm.prefix+"__"+f.Column()))
+		}
+	}
+	return strings.Join(cols, ", ")
+}
+
+// buildSelect renders the SELECT statement for cols and the builder's state.
+// limit overrides the builder's own limit when it is not negative.
+func (b *Builder) buildSelect(cols string, limit int) string {
+	d := b.db.Dialect
+
+	var sb strings.Builder
+	sb.WriteString("SELECT ")
+	if b.distinct {
+		sb.WriteString("DISTINCT ")
+	}
+	sb.WriteString(cols)
+	b.writeFrom(&sb)
+
+	if len(b.orderStmt) > 0 {
+		sb.WriteString(" ORDER BY ")
+		sb.WriteString(strings.Join(b.orderStmt, ", "))
+	}
+
+	if limit < 0 {
+		limit = b.limit
+	}
+	if lo := d.LimitOffset(limit, b.offset); lo != "" {
+		sb.WriteString(" ")
+		sb.WriteString(lo)
+	}
+
+	return dialect.Rebind(d, sb.String())
+}
+
+// writeFrom writes the FROM, WHERE, GROUP BY and HAVING clauses.
+func (b *Builder) writeFrom(sb *strings.Builder) {
+	sb.WriteString(" FROM ")
+	sb.WriteString(b.db.Dialect.Quote(b.schema.TableName))
+
+	for _, j := range b.joinStmt {
+		sb.WriteString(" ")
+		sb.WriteString(j)
+	}
+
+	conds := b.whereStmt
+	if f, ok := b.schema.DeletedAtField(); ok && !b.db.Unscoped {
+		conds = append([]string{b.qualify(f.Column()) + " IS NULL"}, conds...)
+	}
+	if w := joinWhere(conds); w != "" {
+		sb.WriteString(" ")
+		sb.WriteString(w)
+	}
+
+	if len(b.groupCols) > 0 {
+		sb.WriteString(" GROUP BY ")
+		sb.WriteString(b.columnList(b.groupCols))
+	}
+
+	if len(b.havingStmt) > 0 {
+		sb.WriteString(" HAVING ")
+		sb.WriteString(and(b.havingStmt))
+	}
+}
+
+// queryArgs returns the bind values in the order their clauses appear in the query.
+func (b *Builder) queryArgs() []any {
+	args := make([]any, 0, len(b.joinArgs)+len(b.args)+len(b.havingArgs))
+	args = append(args, b.joinArgs...)
+	args = append(args, b.args...)
+	return append(args, b.havingArgs...)
+}
+
 // Find executes SELECT * FROM table WHERE ... and fills result.
 func (b *Builder) Find(dest any) error {
-	var sb strings.Builder
-	sb.WriteString("SELECT * FROM ")
-	sb.WriteString(b.schema.TableName)
+	return b.FindContext(b.context(), dest)
+}
+
+// FindContext is like Find but runs the query with the provided context.
+func (b *Builder) FindContext(ctx context.Context, dest any) error {
+	if b.err != nil {
+		return b.err
+	}
+
+	query := b.buildSelect(b.columns(), -1)
+
+	ctx, cancel := b.db.WithTimeout(ctx)
+	defer cancel()
+
+	rows, err := b.db.Conn().QueryContext(ctx, query, b.queryArgs()...)
+	if err != nil {
+		return fmt.Errorf("query failed: %w", err)
+	}
+	defer rows.Close()
+
+	return utils.ScanRowsWith(rows, dest, b.db.Naming)
+}
+
+// Scan executes the query and maps the result columns into dest by name.
+// Unlike Find, dest does not have to be the model type: it may be a pointer
+// to any struct slice, or a pointer to a struct to receive the first row.
+// It is meant for Group/Having and aggregate queries, e.g.
+//
+//	type AgeCount struct {
+//		Age   int `db:"age"`
+//		Total int `db:"total"`
+//	}
+//	var counts []AgeCount
+//	err := db.Model(&User{}).Select("age", "COUNT(*) AS total").Group("age").Scan(&counts)
+func (b *Builder) Scan(dest any) error {
+	return b.ScanContext(b.context(), dest)
+}
 
-	if len(b.whereStmt) > 0 {
-		sb.WriteString(" WHERE ")
-		sb.WriteString(strings.Join(b.whereStmt, " AND "))
+// ScanContext is like Scan but runs the query with the provided context.
+func (b *Builder) ScanContext(ctx context.Context, dest any) error {
+	if b.err != nil {
+		return b.err
 	}
 
-	query := sb.String()
-	rows, err := b.db.SQL.Query(query, b.args...)
+	query := b.buildSelect(b.columns(), -1)
+
+	ctx, cancel := b.db.WithTimeout(ctx)
+	defer cancel()
+
+	rows, err := b.db.Conn().QueryContext(ctx, query, b.queryArgs()...)
 	if err != nil {
 		return fmt.Errorf("query failed: %w", err)
 	}
 	defer rows.Close()
 
-	return utils.ScanRows(rows, dest)
+	if v := reflect.ValueOf(dest); v.Kind() == reflect.Ptr && v.Elem().Kind() == reflect.Struct {
+		return utils.ScanFirstWith(rows, dest, b.db.Naming)
+	}
+	return utils.ScanRowsWith(rows, dest, b.db.Naming)
 }
 
+// OnConflict makes Create an upsert that resolves unique key conflicts as described by c.
+func (b *Builder) OnConflict(c clause.OnConflict) *Builder {
+	b.onConflict = &c
+	return b
+}
+
+// Create inserts value into the builder's table.
 func (b *Builder) Create(value any) error {
-	return executor.Create(b.db.SQL, b.modelRef, value)
+	return b.CreateContext(b.context(), value)
+}
+
+// CreateContext is like Create but runs the query with the provided context.
+func (b *Builder) CreateContext(ctx context.Context, value any) error {
+	if b.onConflict != nil {
+		return executor.UpsertContext(b.db, ctx, b.modelRef, value, *b.onConflict)
+	}
+	return executor.CreateContext(b.db, ctx, b.modelRef, value)
+}
+
+// CreateInBatches inserts a slice of structs using multi-row INSERTs
+// of at most batchSize rows each, as an upsert when OnConflict is set.
+func (b *Builder) CreateInBatches(value any, batchSize int) error {
+	return b.CreateInBatchesContext(b.context(), value, batchSize)
+}
+
+// CreateInBatchesContext is like CreateInBatches but runs the queries with the provided context.
+func (b *Builder) CreateInBatchesContext(ctx context.Context, value any, batchSize int) error {
+	if b.onConflict != nil {
+		return executor.UpsertInBatchesContext(b.db, ctx, b.modelRef, value, batchSize, *b.onConflict)
+	}
+	return executor.CreateInBatchesContext(b.db, ctx, b.modelRef, value, batchSize)
 }
 
-// First executes SELECT * FROM table WHERE ... LIMIT 1 and fills single struct.
+// First executes SELECT * FROM table WHERE ... ORDER BY ... LIMIT 1 and fills single struct.
 func (b *Builder) First(dest any) error {
-	var sb strings.Builder
-	sb.WriteString("SELECT * FROM ")
-	sb.WriteString(b.schema.TableName)
+	return b.FirstContext(b.context(), dest)
+}
 
-	if len(b.whereStmt) > 0 {
-		sb.WriteString(" WHERE ")
-		sb.WriteString(strings.Join(b.whereStmt, " AND "))
+// FirstContext is like First but runs the query with the provided context.
+func (b *Builder) FirstContext(ctx context.Context, dest any) error {
+	if b.err != nil {
+		return b.err
 	}
-	sb.WriteString(" LIMIT 1")
 
-	query := sb.String()
-	row := b.db.SQL.QueryRow(query, b.args...)
+	query := b.buildSelect(b.columns(), 1)
 
-	schema := b.schema
-	val := reflect.ValueOf(dest)
-	if val.Kind() != reflect.Ptr || val.Elem().Kind() != reflect.Struct {
-		return fmt.Errorf("dest must be pointer to struct")
+	ctx, cancel := b.db.WithTimeout(ctx)
+	defer cancel()
+
+	rows, err := b.db.Conn().QueryContext(ctx, query, b.queryArgs()...)
+	if err != nil {
+		return fmt.Errorf("query failed: %w", err)
 	}
-	elem := val.Elem()
+	defer rows.Close()
+
+	return utils.ScanFirstWith(rows, dest, b.db.Naming)
+}
 
-	// Pemetaan kolom ke field
-	colToField := map[string]reflect.Value{}
-	for _, field := range schema.Fields {
-		fieldVal := elem.FieldByName(field.Name)
-		if fieldVal.IsValid() && fieldVal.CanAddr() {
-			colToField[field.Column()] = fieldVal
+// Update sets the columns in value on the rows matching the builder's
+// conditions and reports how many were affected. value is either a
+// map[string]any keyed by Go field or column name, or a struct (or pointer
+// to one) of the model whose columns are all written, except the primary
+// key, read-only, creation time and DeletedAt fields. UpdatedAt is touched
+// and the version incremented like with Torm.Update. Order and Limit
+// restrict the update to the first matching rows.
+func (b *Builder) Update(value any) (executor.Result, error) {
+	return b.UpdateContext(b.context(), value)
+}
+
+// UpdateContext is like Update but runs the query with the provided context.
+func (b *Builder) UpdateContext(ctx context.Context, value any) (executor.Result, error) {
+	data, err := b.updateMap(value)
+	if err != nil {
+		return executor.Result{}, err
+	}
+	whereClause, args, err := b.writeWhere("Update", b.scope(" IS NULL"))
+	if err != nil {
+		return executor.Result{}, err
+	}
+	return executor.UpdateContext(b.db, ctx, b.modelRef, data, whereClause, args...)
+}
+
+// Updates writes the non-zero fields of value, a struct (or pointer to one)
+// of the model, to the rows matching the builder's conditions. Select forces
+// zero fields to be written and Omit leaves fields out. A field holding a
+// clause.Expr is written as that expression. Otherwise it behaves like Update.
+//
+//	db.Model(&User{}).Where("id = ?", id).Updates(&User{Name: "Totti"})
+//	db.Model(&User{}).Where("id = ?", id).Select("Name", "Age").Updates(&User{Name: "Totti"}) // age = 0
+func (b *Builder) Updates(value any) (executor.Result, error) {
+	return b.UpdatesContext(b.context(), value)
+}
+
+// UpdatesContext is like Updates but runs the query with the provided context.
+func (b *Builder) UpdatesContext(ctx context.Context, value any) (executor.Result, error) {
+	if reflect.Indirect(reflect.ValueOf(value)).Kind() != reflect.Struct {
+		return executor.Result{}, fmt.Errorf("Updates needs a struct, got %T", value)
+	}
+	data, err := b.updateStruct(value, true)
+	if err != nil {
+		return executor.Result{}, err
+	}
+	whereClause, args, err := b.writeWhere("Updates", b.scope(" IS NULL"))
+	if err != nil {
+		return executor.Result{}, err
+	}
+	return executor.UpdateContext(b.db, ctx, b.modelRef, data, whereClause, args...)
+}
+
+// UpdateColumn sets col to val on the rows matching the builder's conditions,
+// without touching UpdatedAt or incrementing the version.
+func (b *Builder) UpdateColumn(col string, val any) (executor.Result, error) {
+	return b.UpdateColumnContext(b.context(), col, val)
+}
+
+// UpdateColumnContext is like UpdateColumn but runs the query with the provided context.
+func (b *Builder) UpdateColumnContext(ctx context.Context, col string, val any) (executor.Result, error) {
+	whereClause, args, err := b.writeWhere("UpdateColumn", b.scope(" IS NULL"))
+	if err != nil {
+		return executor.Result{}, err
+	}
+	return executor.UpdateColumnsContext(b.db, ctx, b.modelRef, map[string]any{col: val}, whereClause, args...)
+}
+
+// Increment atomically adds n to col on the rows matching the builder's
+// conditions, e.g. Where("id = ?", id).Increment("stock", 5).
+func (b *Builder) Increment(col string, n any) (executor.Result, error) {
+	return b.IncrementContext(b.context(), col, n)
+}
+
+// IncrementContext is like Increment but runs the query with the provided context.
+func (b *Builder) IncrementContext(ctx context.Context, col string, n any) (executor.Result, error) {
+	return b.step(ctx, col, "+", n)
+}
+
+// Decrement atomically subtracts n from col on the rows matching the builder's conditions.
+func (b *Builder) Decrement(col string, n any) (executor.Result, error) {
+	return b.DecrementContext(b.context(), col, n)
+}
+
+// DecrementContext is like Decrement but runs the query with the provided context.
+func (b *Builder) DecrementContext(ctx context.Context, col string, n any) (executor.Result, error) {
+	return b.step(ctx, col, "-", n)
+}
+
+// step updates col to "col <op> n" in a single statement.
+func (b *Builder) step(ctx context.Context, col string, op string, n any) (executor.Result, error) {
+	f, ok := b.lookUpField(col)
+	if !ok {
+		return executor.Result{}, b.err
+	}
+	expr := clause.Expr{SQL: b.db.Dialect.Quote(f.Column()) + " " + op + " ?", Vars: []any{n}}
+	return b.UpdateContext(ctx, map[string]any{f.Name: expr})
+}
+
+// Delete deletes the rows matching the builder's conditions and reports
+// how many were affected. Models with a DeletedAt field are soft-deleted
+// unless Unscoped is used.
+func (b *Builder) Delete() (executor.Result, error) {
+	return b.DeleteContext(b.context())
+}
+
+// DeleteContext is like Delete but runs the query with the provided context.
+func (b *Builder) DeleteContext(ctx context.Context) (executor.Result, error) {
+	whereClause, args, err := b.writeWhere("Delete", b.scope(" IS NULL"))
+	if err != nil {
+		return executor.Result{}, err
+	}
+	return executor.DeleteContext(b.db, ctx, b.modelRef, whereClause, args...)
+}
+
+// Restore undeletes the soft-deleted rows matching the builder's conditions.
+func (b *Builder) Restore() (executor.Result, error) {
+	return b.RestoreContext(b.context())
+}
+
+// RestoreContext is like Restore but runs the query with the provided context.
+func (b *Builder) RestoreContext(ctx context.Context) (executor.Result, error) {
+	whereClause, args, err := b.writeWhere("Restore", b.scope(" IS NOT NULL"))
+	if err != nil {
+		return executor.Result{}, err
+	}
+	return executor.RestoreContext(b.db, ctx, b.modelRef, whereClause, args...)
+}
+
+// updateMap converts the value given to Update into column values keyed by
+// Go field or column name.
+func (b *Builder) updateMap(value any) (map[string]any, error) {
+	if data, ok := value.(map[string]any); ok {
+		return data, nil
+	}
+	if reflect.Indirect(reflect.ValueOf(value)).Kind() != reflect.Struct {
+		return nil, fmt.Errorf("update value must be a map[string]any or struct, got %T", value)
+	}
+	return b.updateStruct(value, false)
+}
+
+// updateStruct converts a struct into column values keyed by Go field name,
+// filtered by Select and Omit. With skipZero only its non-zero fields are
+// kept unless they are selected.
+func (b *Builder) updateStruct(value any, skipZero bool) (map[string]any, error) {
+	rv := reflect.Indirect(reflect.ValueOf(value))
+
+	selected := map[string]bool{}
+	for _, c := range b.selectCols {
+		if f, ok := b.schema.LookUpField(c); ok {
+			selected[f.Column()] = true
+		}
+	}
+	omitted := map[string]bool{}
+	for _, c := range b.omitCols {
+		omitted[c] = true
+	}
+
+	data := map[string]any{}
+	for _, f := range model.ParseWith(value, b.db.Naming).Fields {
+		if f.PK || f.ReadOnly || omitted[f.Column()] {
+			continue
+		}
+		fv := f.ValueOf(rv)
+		// An expression is written as SQL; an empty one sets nothing
+		if e, ok := exprOf(fv); ok {
+			if e.SQL != "" && (len(selected) == 0 || selected[f.Column()]) {
+				data[f.Name] = e
+			}
+			continue
 		}
+		switch {
+		case len(selected) > 0:
+			// A zero version is not an expected one
+			if !selected[f.Column()] || (f.Version && fv.IsZero()) {
+				continue
+			}
+		case f.AutoCreateTime || f.AutoUpdateTime || f.SoftDelete:
+			continue
+		case fv.IsZero() && (skipZero || f.Version || f.HasDefault):
+			// An unset default keeps the database's value
+			continue
+		}
+		data[f.Name] = fv.Interface()
 	}
+	return data, nil
+}
 
-	// Siapkan target scan
-	columns := make([]string, len(schema.Fields))
-	values := make([]any, len(columns))
-	for i, f := range schema.Fields {
-		columns[i] = f.Column()
-		if fv, ok := colToField[f.Column()]; ok {
-			values[i] = fv.Addr().Interface()
-		} else {
-			var dummy any
-			values[i] = &dummy
+// exprOf returns the expression held by fv: a clause.Expr, a pointer to one,
+// or an interface holding either. A nil pointer yields an empty Expr.
+func exprOf(fv reflect.Value) (clause.Expr, bool) {
+	if fv.Kind() == reflect.Interface {
+		if fv.IsNil() {
+			return clause.Expr{}, false
 		}
+		fv = fv.Elem()
+	}
+	switch e := fv.Interface().(type) {
+	case clause.Expr:
+		return e, true
+	case *clause.Expr:
+		if e == nil {
+			return clause.Expr{}, true
+		}
+		return *e, true
+	}
+	return clause.Expr{}, false
+}
+
+// scope returns the condition on the DeletedAt column with the given test,
+// or "" if the model has none or the builder is unscoped.
+func (b *Builder) scope(test string) string {
+	f, ok := b.schema.DeletedAtField()
+	if !ok || b.db.Unscoped {
+		return ""
 	}
+	return b.db.Dialect.Quote(f.Column()) + test
+}
 
-	if err := row.Scan(values...); err != nil {
-		return fmt.Errorf("scan failed: %w", err)
+// writeWhere renders the WHERE clause and bind values for an UPDATE or DELETE
+// run by op. The soft-delete scope is left to the executor, except inside the
+// subquery that applies Order and Limit on dialects whose UPDATE and DELETE
+// do not accept them.
+func (b *Builder) writeWhere(op string, scope string) (string, []any, error) {
+	if b.err != nil {
+		return "", nil, b.err
+	}
+	if len(b.joinStmt) > 0 {
+		return "", nil, fmt.Errorf("%s does not support joins", op)
+	}
+	if len(b.groupCols) > 0 || len(b.havingStmt) > 0 {
+		return "", nil, fmt.Errorf("%s does not support Group or Having", op)
+	}
+	if b.offset >= 0 {
+		return "", nil, fmt.Errorf("%s does not support Offset", op)
+	}
+	// Order and Limit alone do not make a write conditional
+	if len(b.whereStmt) == 0 && !b.db.AllowGlobalUpdate {
+		return "", nil, fmt.Errorf("%w: %s on %s", executor.ErrMissingWhereClause, op, b.schema.Table())
+	}
+
+	d := b.db.Dialect
+	conds := b.whereStmt
+	if len(b.orderStmt) == 0 && b.limit < 0 {
+		return joinWhere(conds), b.args, nil
+	}
+
+	var tail strings.Builder
+	if len(b.orderStmt) > 0 {
+		tail.WriteString(" ORDER BY ")
+		tail.WriteString(strings.Join(b.orderStmt, ", "))
+	}
+	if lo := d.LimitOffset(b.limit, -1); lo != "" {
+		tail.WriteString(" ")
+		tail.WriteString(lo)
+	}
+
+	if d.WriteLimit() {
+		return strings.TrimSpace(joinWhere(conds) + tail.String()), b.args, nil
+	}
+	if b.limit < 0 {
+		// The order of an unlimited write makes no difference
+		return joinWhere(conds), b.args, nil
 	}
 
-	return nil
+	// Otherwise pick the rows by primary key in a subquery
+	if len(b.schema.PrimaryFields) == 0 {
+		return "", nil, fmt.Errorf("%s with Order or Limit needs a primary key on %s", op, b.schema.Table())
+	}
+	pks := make([]string, len(b.schema.PrimaryFields))
+	for i, f := range b.schema.PrimaryFields {
+		pks[i] = d.Quote(f.Column())
+	}
+	keys := strings.Join(pks, ", ")
+	if len(pks) > 1 {
+		keys = "(" + keys + ")"
+	}
+
+	if scope != "" {
+		conds = append([]string{scope}, conds...)
+	}
+
+	sub := "SELECT " + strings.Join(pks, ", ") + " FROM " + d.Quote(b.schema.Table())
+	if w := joinWhere(conds); w != "" {
+		sub += " " + w
+	}
+	return "WHERE " + keys + " IN (" + sub + tail.String() + ")", b.args, nil
+}
+
+// joinWhere renders conds as a WHERE clause, or "" if there are none.
+func joinWhere(conds []string) string {
+	if len(conds) == 0 {
+		return ""
+	}
+	return "WHERE " + and(conds)
+}
+
+// and joins conds with AND. Several conditions are each parenthesized, so
+// an OR inside one of them does not spill over into the others.
+func and(conds []string) string {
+	if len(conds) == 1 {
+		return conds[0]
+	}
+	wrapped := make([]string, len(conds))
+	for i, c := range conds {
+		wrapped[i] = "(" + c + ")"
+	}
+	return strings.Join(wrapped, " AND ")
 }
@@ -0,0 +1,54 @@
+package query
+
+import (
+	"testing"
+
+	"github.com/adipras/torm/db"
+	"github.com/adipras/torm/dialect"
+)
+
+func TestWhereKeepsConditionsApart(t *testing.T) {
+	b := NewBuilder(&db.DB{Dialect: dialect.MySQL{}}, &player{}).Where("name = ? OR club = ?", "a", "b").Where("id = ?", 5)
+
+	for _, op := range []string{"Update", "Delete"} {
+		where, args, err := b.writeWhere(op, "")
+		if err != nil {
+			t.Fatalf("%s: writeWhere() failed: %v", op, err)
+		}
+		if want := "WHERE (name = ? OR club = ?) AND (id = ?)"; where != want || len(args) != 3 {
+			t.Errorf("%s: writeWhere() = %s %v, want %s", op, where, args, want)
+		}
+	}
+
+	if got, want := b.countQuery(), "SELECT COUNT(*) FROM `players` WHERE (name = ? OR club = ?) AND (id = ?)"; got != want {
+		t.Errorf("countQuery() = %s, want %s", got, want)
+	}
+}
+
+func TestWriteWhereLimit(t *testing.T) {
+	cases := []struct {
+		d    dialect.Dialect
+		want string
+	}{
+		// MySQL accepts ORDER BY and LIMIT on UPDATE and DELETE
+		{dialect.MySQL{}, "WHERE club = ? ORDER BY id LIMIT 2"},
+		{dialect.Postgres{}, `WHERE "id" IN (SELECT "id" FROM "players" WHERE club = ? ORDER BY id LIMIT 2)`},
+		{dialect.SQLite{}, `WHERE "id" IN (SELECT "id" FROM "players" WHERE club = ? ORDER BY id LIMIT 2)`},
+	}
+	for _, c := range cases {
+		b := NewBuilder(&db.DB{Dialect: c.d}, &player{}).Where("club = ?", "Roma").Order("id").Limit(2)
+		where, args, err := b.writeWhere("Delete", "")
+		if err != nil {
+			t.Fatalf("%s: writeWhere() failed: %v", c.d.Name(), err)
+		}
+		if where != c.want || len(args) != 1 {
+			t.Errorf("%s: writeWhere() = %s %v, want %s", c.d.Name(), where, args, c.want)
+		}
+	}
+
+	// Without a limit, the order of the write does not matter
+	b := NewBuilder(&db.DB{Dialect: dialect.Postgres{}}, &player{}).Where("club = ?", "Roma").Order("id")
+	if where, _, _ := b.writeWhere("Update", ""); where != "WHERE club = ?" {
+		t.Errorf("unlimited writeWhere() = %s, want WHERE club = ?", where)
+	}
+}
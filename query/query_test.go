@@ -1,11 +1,16 @@
 package query_test
 
 import (
+	"context"
+	"errors"
 	"log"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/adipras/torm"
+	"github.com/adipras/torm/clause"
+	"github.com/adipras/torm/query"
 	_ "github.com/go-sql-driver/mysql"
 )
 
@@ -174,3 +179,1105 @@ func TestRawSQL(t *testing.T) {
 		t.Errorf("expected 2 rows from RawSQL, got %d", count)
 	}
 }
+
+func TestTransaction(t *testing.T) {
+	setupTable(t)
+
+	// Rolled back on error
+	errBoom := errors.New("boom")
+	err := testDB.Transaction(context.Background(), func(tx *torm.Torm) error {
+		if err := tx.Create(&User{}, &User{Name: "Rolled", Age: 20}); err != nil {
+			return err
+		}
+		return errBoom
+	})
+	if !errors.Is(err, errBoom) {
+		t.Fatalf("expected errBoom, got: %v", err)
+	}
+
+	// Committed, with a nested savepoint rolled back
+	err = testDB.Transaction(context.Background(), func(tx *torm.Torm) error {
+		if err := tx.Create(&User{}, &User{Name: "Kept", Age: 21}); err != nil {
+			return err
+		}
+		_ = tx.Transaction(context.Background(), func(tx2 *torm.Torm) error {
+			if err := tx2.Create(&User{}, &User{Name: "Nested", Age: 22}); err != nil {
+				return err
+			}
+			return errBoom
+		})
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Transaction() failed: %v", err)
+	}
+
+	var users []User
+	if err := testDB.Find(&User{}, &users); err != nil {
+		t.Fatalf("Find() failed: %v", err)
+	}
+	if len(users) != 1 || users[0].Name != "Kept" {
+		t.Errorf("expected only 'Kept' to be committed, got %+v", users)
+	}
+}
+
+func TestContextCancellation(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	var users []User
+	err := testDB.WithContext(ctx).Model(&User{}).Where("age >= ?", 18).Find(&users)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled from chain Find, got: %v", err)
+	}
+
+	err = testDB.CreateContext(ctx, &User{}, &User{Name: "Never", Age: 1})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled from CreateContext, got: %v", err)
+	}
+
+	if _, err := testDB.Model(&User{}).CountContext(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled from CountContext, got: %v", err)
+	}
+	var names []string
+	if err := testDB.Model(&User{}).PluckContext(ctx, "name", &names); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled from PluckContext, got: %v", err)
+	}
+}
+
+func TestTransactionContext(t *testing.T) {
+	setupTable(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var createErr error
+	_ = testDB.Transaction(ctx, func(tx *torm.Torm) error {
+		cancel()
+		createErr = tx.Create(&User{}, &User{Name: "Never", Age: 1})
+		return createErr
+	})
+	if !errors.Is(createErr, context.Canceled) {
+		t.Errorf("expected queries in the transaction to use its ctx, got: %v", createErr)
+	}
+}
+
+func TestReturningNotSupportedOnMySQL(t *testing.T) {
+	var users []User
+	err := testDB.UpdateReturning(&User{}, &users, map[string]any{"age": 1}, "WHERE id = ?", 1)
+	if !errors.Is(err, torm.ErrReturningNotSupported) {
+		t.Errorf("expected ErrReturningNotSupported from UpdateReturning, got: %v", err)
+	}
+
+	err = testDB.DeleteReturning(&User{}, &users, "WHERE id = ?", 1)
+	if !errors.Is(err, torm.ErrReturningNotSupported) {
+		t.Errorf("expected ErrReturningNotSupported from DeleteReturning, got: %v", err)
+	}
+}
+
+func TestOrderLimitOffsetDistinct(t *testing.T) {
+	setupTable(t)
+
+	_, err := testDB.DB.SQL.Exec(`INSERT INTO users (name, age) VALUES
+		('Alice', 25), ('Bob', 17), ('Charlie', 20), ('Dave', 20)`)
+	if err != nil {
+		t.Fatalf("failed to insert: %v", err)
+	}
+
+	var users []User
+	err = testDB.Model(&User{}).Order("age DESC").Order("name").Limit(2).Offset(1).Find(&users)
+	if err != nil {
+		t.Fatalf("Find() failed: %v", err)
+	}
+	if len(users) != 2 || users[0].Name != "Charlie" || users[1].Name != "Dave" {
+		t.Errorf("expected [Charlie Dave], got %+v", users)
+	}
+
+	var oldest User
+	err = testDB.Model(&User{}).Order("age DESC").First(&oldest)
+	if err != nil {
+		t.Fatalf("First() failed: %v", err)
+	}
+	if oldest.Name != "Alice" {
+		t.Errorf("expected First to honour Order and return Alice, got %s", oldest.Name)
+	}
+
+	var ages []User
+	err = testDB.Model(&User{}).Distinct("age").Order("age").Find(&ages)
+	if err != nil {
+		t.Fatalf("Distinct Find() failed: %v", err)
+	}
+	if len(ages) != 3 {
+		t.Errorf("expected 3 distinct ages, got %d", len(ages))
+	}
+}
+
+func TestSelectAndOmit(t *testing.T) {
+	setupTable(t)
+
+	user := User{Name: "Pirlo", Age: 44}
+	if err := testDB.Create(&User{}, &user); err != nil {
+		t.Fatalf("Create() failed: %v", err)
+	}
+
+	var selected User
+	err := testDB.Model(&User{}).Select("ID", "name").Where("id = ?", user.ID).First(&selected)
+	if err != nil {
+		t.Fatalf("Select First() failed: %v", err)
+	}
+	if selected.Name != "Pirlo" || selected.Age != 0 {
+		t.Errorf("expected only id and name to be selected, got %+v", selected)
+	}
+
+	var omitted []User
+	err = testDB.Model(&User{}).Omit("name").Find(&omitted)
+	if err != nil {
+		t.Fatalf("Omit Find() failed: %v", err)
+	}
+	if len(omitted) != 1 || omitted[0].Name != "" || omitted[0].Age != 44 {
+		t.Errorf("expected name to be omitted, got %+v", omitted)
+	}
+
+	err = testDB.Model(&User{}).Select("nope").Find(&omitted)
+	if err == nil {
+		t.Error("expected error for unknown column in Select")
+	}
+}
+
+func TestAggregates(t *testing.T) {
+	setupTable(t)
+
+	_, err := testDB.DB.SQL.Exec(`INSERT INTO users (name, age) VALUES
+		('Alice', 25), ('Bob', 17), ('Charlie', 20)`)
+	if err != nil {
+		t.Fatalf("failed to insert: %v", err)
+	}
+
+	adults := func() *query.Builder { return testDB.Model(&User{}).Where("age >= ?", 18) }
+
+	count, err := adults().Count()
+	if err != nil || count != 2 {
+		t.Errorf("Count() = %d, %v; want 2", count, err)
+	}
+
+	var sum int64
+	if err := adults().Sum("age", &sum); err != nil || sum != 45 {
+		t.Errorf("Sum() = %v, %v; want 45", sum, err)
+	}
+
+	avg, err := adults().Avg("age")
+	if err != nil || avg != 22.5 {
+		t.Errorf("Avg() = %v, %v; want 22.5", avg, err)
+	}
+
+	var minAge, maxAge int
+	if err := testDB.Model(&User{}).Min("age", &minAge); err != nil || minAge != 17 {
+		t.Errorf("Min() = %v, %v; want 17", minAge, err)
+	}
+	if err := testDB.Model(&User{}).Max("Age", &maxAge); err != nil || maxAge != 25 {
+		t.Errorf("Max() = %v, %v; want 25", maxAge, err)
+	}
+
+	var firstName string
+	if err := testDB.Model(&User{}).Min("name", &firstName); err != nil || firstName != "Alice" {
+		t.Errorf("Min(name) = %q, %v; want Alice", firstName, err)
+	}
+
+	noSum := int64(-1)
+	if err := testDB.Model(&User{}).Where("age > ?", 100).Sum("age", &noSum); err != nil || noSum != -1 {
+		t.Errorf("Sum() without rows = %v, %v; want dest unchanged", noSum, err)
+	}
+
+	if count, err := testDB.Model(&User{}).Distinct().Count(); err != nil || count != 3 {
+		t.Errorf("Distinct().Count() = %d, %v; want 3", count, err)
+	}
+
+	exists, err := testDB.Model(&User{}).Where("name = ?", "Bob").Exists()
+	if err != nil || !exists {
+		t.Errorf("Exists() = %v, %v; want true", exists, err)
+	}
+
+	var names []string
+	err = adults().Order("name").Pluck("name", &names)
+	if err != nil {
+		t.Fatalf("Pluck() failed: %v", err)
+	}
+	if len(names) != 2 || names[0] != "Alice" || names[1] != "Charlie" {
+		t.Errorf("Pluck() = %v; want [Alice Charlie]", names)
+	}
+}
+
+func TestGroupHavingScan(t *testing.T) {
+	setupTable(t)
+
+	_, err := testDB.DB.SQL.Exec(`INSERT INTO users (name, age) VALUES
+		('Alice', 20), ('Bob', 20), ('Charlie', 30), ('Dave', 40), ('Eve', 40)`)
+	if err != nil {
+		t.Fatalf("failed to insert: %v", err)
+	}
+
+	type AgeCount struct {
+		Age   int `db:"age"`
+		Total int `db:"total"`
+	}
+
+	var counts []AgeCount
+	err = testDB.Model(&User{}).
+		Select("age", "COUNT(*) AS total").
+		Group("age").
+		Having("COUNT(*) > ?", 1).
+		Order("age").
+		Scan(&counts)
+	if err != nil {
+		t.Fatalf("Scan() failed: %v", err)
+	}
+	if len(counts) != 2 || counts[0] != (AgeCount{20, 2}) || counts[1] != (AgeCount{40, 2}) {
+		t.Errorf("expected [{20 2} {40 2}], got %+v", counts)
+	}
+
+	groups, err := testDB.Model(&User{}).Group("age").Count()
+	if err != nil || groups != 3 {
+		t.Errorf("grouped Count() = %d, %v; want 3", groups, err)
+	}
+}
+
+type Order struct {
+	ID     int `db:"id"`
+	UserID int `db:"user_id"`
+	Total  int `db:"total"`
+}
+
+func TestJoins(t *testing.T) {
+	setupTable(t)
+
+	_, err := testDB.DB.SQL.Exec(`CREATE TABLE IF NOT EXISTS orders (
+		id INT PRIMARY KEY AUTO_INCREMENT,
+		user_id INT,
+		total INT
+	)`)
+	if err != nil {
+		t.Fatalf("failed to create orders table: %v", err)
+	}
+	if _, err := testDB.DB.SQL.Exec("DELETE FROM orders"); err != nil {
+		t.Fatalf("failed to clear orders table: %v", err)
+	}
+
+	user := User{Name: "Nesta", Age: 48}
+	if err := testDB.Create(&User{}, &user); err != nil {
+		t.Fatalf("Create() failed: %v", err)
+	}
+	if err := testDB.Create(&Order{}, &Order{UserID: user.ID, Total: 150}); err != nil {
+		t.Fatalf("Create() order failed: %v", err)
+	}
+
+	type UserOrder struct {
+		User
+		Order Order
+	}
+
+	var typed []UserOrder
+	err = testDB.Model(&User{}).InnerJoin(&Order{}, "orders.user_id = users.id").Find(&typed)
+	if err != nil {
+		t.Fatalf("InnerJoin Find() failed: %v", err)
+	}
+	if len(typed) != 1 || typed[0].Name != "Nesta" || typed[0].Order.Total != 150 {
+		t.Errorf("expected joined user and order, got %+v", typed)
+	}
+
+	var raw []UserOrder
+	err = testDB.Model(&User{}).
+		Joins("JOIN orders o ON o.user_id = users.id").
+		Select("users.*", "o.total AS order__total").
+		Where("o.total > ?", 100).
+		Find(&raw)
+	if err != nil {
+		t.Fatalf("Joins Find() failed: %v", err)
+	}
+	if len(raw) != 1 || raw[0].ID != user.ID || raw[0].Order.Total != 150 {
+		t.Errorf("expected aliased order column to be scanned, got %+v", raw)
+	}
+	// Without Select the joined orders.id must not replace users.id
+	if _, err := testDB.DB.SQL.Exec("INSERT INTO orders (id, user_id, total) VALUES (?, ?, ?)", user.ID+1000, user.ID, 300); err != nil {
+		t.Fatalf("failed to insert order: %v", err)
+	}
+	var plain []User
+	err = testDB.Model(&User{}).
+		Joins("JOIN orders o ON o.user_id = users.id").
+		Where("o.total = ?", 300).
+		Find(&plain)
+	if err != nil {
+		t.Fatalf("Joins Find() without Select failed: %v", err)
+	}
+	if len(plain) != 1 || plain[0].ID != user.ID {
+		t.Errorf("expected the user's own ID %d, got %+v", user.ID, plain)
+	}
+
+	// A user without orders gets NULL order columns from a LEFT JOIN
+	loner := User{Name: "Costacurta", Age: 59}
+	if err := testDB.Create(&User{}, &loner); err != nil {
+		t.Fatalf("Create() failed: %v", err)
+	}
+	var left []UserOrder
+	err = testDB.Model(&User{}).LeftJoin(&Order{}, "orders.user_id = users.id").Where("users.id = ?", loner.ID).Find(&left)
+	if err != nil {
+		t.Fatalf("LeftJoin Find() failed: %v", err)
+	}
+	if len(left) != 1 || left[0].Name != "Costacurta" || left[0].Order != (Order{}) {
+		t.Errorf("expected the user with a zero order, got %+v", left)
+	}
+
+	type UserOrderPtr struct {
+		User
+		Order *Order
+	}
+	var leftPtr []UserOrderPtr
+	err = testDB.Model(&User{}).LeftJoin(&Order{}, "orders.user_id = users.id").Where("users.id = ?", loner.ID).Find(&leftPtr)
+	if err != nil {
+		t.Fatalf("LeftJoin Find() into *Order failed: %v", err)
+	}
+	if len(leftPtr) != 1 || leftPtr[0].Order != nil {
+		t.Errorf("expected a nil order for the user without orders, got %+v", leftPtr)
+	}
+}
+
+func TestCreateBatch(t *testing.T) {
+	setupTable(t)
+
+	users := []User{{Name: "Cafu", Age: 54}, {Name: "Aldair", Age: 59}, {Name: "Candela", Age: 50}}
+	if err := testDB.CreateInBatches(&User{}, users, 2); err != nil {
+		t.Fatalf("CreateInBatches() failed: %v", err)
+	}
+	for i, u := range users {
+		if u.ID == 0 {
+			t.Errorf("expected ID to be set on users[%d]", i)
+		}
+	}
+	if users[1].ID != users[0].ID+1 {
+		t.Errorf("expected consecutive IDs, got %d and %d", users[0].ID, users[1].ID)
+	}
+
+	more := []*User{{Name: "Zago", Age: 52}}
+	if err := testDB.Create(&User{}, more); err != nil {
+		t.Fatalf("Create() with slice failed: %v", err)
+	}
+	if more[0].ID == 0 {
+		t.Error("expected ID to be set on pointer element")
+	}
+
+	count, err := testDB.Model(&User{}).Count()
+	if err != nil || count != 4 {
+		t.Errorf("Count() = %d, %v; want 4", count, err)
+	}
+}
+
+type Product struct {
+	ID    int    `db:"id"`
+	SKU   string `db:"sku"`
+	Price int    `db:"price"`
+	Stock int    `db:"stock"`
+}
+
+func TestUpsert(t *testing.T) {
+	_, err := testDB.DB.SQL.Exec(`CREATE TABLE IF NOT EXISTS products (
+		id INT PRIMARY KEY AUTO_INCREMENT,
+		sku VARCHAR(64) UNIQUE,
+		price INT,
+		stock INT
+	)`)
+	if err != nil {
+		t.Fatalf("failed to create products table: %v", err)
+	}
+	if _, err := testDB.DB.SQL.Exec("DELETE FROM products"); err != nil {
+		t.Fatalf("failed to clear products table: %v", err)
+	}
+
+	if err := testDB.Create(&Product{}, &Product{SKU: "ball", Price: 10, Stock: 1}); err != nil {
+		t.Fatalf("Create() failed: %v", err)
+	}
+
+	// Update only the price of the existing row and bump its stock
+	err = testDB.Upsert(&Product{}, &Product{SKU: "ball", Price: 12, Stock: 99}, torm.OnConflict{
+		Columns:   []string{"sku"},
+		DoUpdates: []string{"price"},
+		Set:       map[string]any{"stock": torm.Expr("stock + ?", 2)},
+	})
+	if err != nil {
+		t.Fatalf("Upsert() DoUpdates failed: %v", err)
+	}
+
+	existing := Product{SKU: "ball", Price: 12}
+	if err := testDB.Upsert(&Product{}, &existing, torm.OnConflict{Columns: []string{"sku"}, DoUpdates: []string{"price"}}); err != nil {
+		t.Fatalf("Upsert() of an existing row failed: %v", err)
+	}
+	if existing.ID != 0 {
+		t.Errorf("expected no ID to be guessed for an upserted row, got %d", existing.ID)
+	}
+
+	err = testDB.Upsert(&Product{}, &Product{SKU: "ball"}, torm.OnConflict{Columns: []string{"barcode"}, DoNothing: true})
+	if !errors.Is(err, torm.ErrUnknownColumn) {
+		t.Errorf("expected ErrUnknownColumn for an unknown conflict target, got: %v", err)
+	}
+
+	var ball Product
+	if err := testDB.Model(&Product{}).Where("sku = ?", "ball").First(&ball); err != nil {
+		t.Fatalf("First() failed: %v", err)
+	}
+	if ball.Price != 12 || ball.Stock != 3 {
+		t.Errorf("expected price 12 and stock 3, got %+v", ball)
+	}
+
+	// Existing row kept, new row inserted
+	err = testDB.Model(&Product{}).OnConflict(torm.OnConflict{DoNothing: true}).Create([]Product{
+		{SKU: "ball", Price: 1, Stock: 1},
+		{SKU: "boots", Price: 50, Stock: 5},
+	})
+	if err != nil {
+		t.Fatalf("Upsert() DoNothing failed: %v", err)
+	}
+
+	count, err := testDB.Model(&Product{}).Count()
+	if err != nil || count != 2 {
+		t.Errorf("Count() = %d, %v; want 2", count, err)
+	}
+
+	// CreateInBatches keeps the conflict clause
+	err = testDB.Model(&Product{}).OnConflict(torm.OnConflict{DoNothing: true}).CreateInBatches([]Product{
+		{SKU: "ball", Price: 1, Stock: 1},
+		{SKU: "boots", Price: 1, Stock: 1},
+		{SKU: "gloves", Price: 30, Stock: 2},
+	}, 2)
+	if err != nil {
+		t.Fatalf("OnConflict().CreateInBatches() failed: %v", err)
+	}
+	if count, _ := testDB.Model(&Product{}).Count(); count != 3 {
+		t.Errorf("expected 3 products after the batched upsert, got %d", count)
+	}
+}
+
+func TestPrimaryKeyOperations(t *testing.T) {
+	setupTable(t)
+
+	user := User{Name: "Maldini", Age: 56}
+	if err := testDB.Save(&user); err != nil {
+		t.Fatalf("Save() insert failed: %v", err)
+	}
+	if user.ID == 0 {
+		t.Fatal("expected Save to set ID on insert")
+	}
+
+	user.Age = 57
+	if err := testDB.Save(&user); err != nil {
+		t.Fatalf("Save() update failed: %v", err)
+	}
+
+	var found User
+	if err := testDB.FindByID(&found, user.ID); err != nil {
+		t.Fatalf("FindByID() failed: %v", err)
+	}
+	if found.Age != 57 {
+		t.Errorf("expected age 57 after Save, got %d", found.Age)
+	}
+
+	if err := testDB.Update(&User{}, map[string]any{"age": 58}, "WHERE id = ?", user.ID); err != nil {
+		t.Fatalf("Update() failed: %v", err)
+	}
+	if err := testDB.Reload(&user); err != nil {
+		t.Fatalf("Reload() failed: %v", err)
+	}
+	if user.Age != 58 {
+		t.Errorf("expected age 58 after Reload, got %d", user.Age)
+	}
+
+	if err := testDB.DeleteModel(&user); err != nil {
+		t.Fatalf("DeleteModel() failed: %v", err)
+	}
+	if err := testDB.FindByID(&found, user.ID); err != torm.ErrNoRows {
+		t.Errorf("expected ErrNoRows after DeleteModel, got: %v", err)
+	}
+
+	if err := testDB.DeleteModel(&User{}); !errors.Is(err, torm.ErrMissingPrimaryKey) {
+		t.Errorf("expected ErrMissingPrimaryKey for zero ID, got: %v", err)
+	}
+}
+
+type Doc struct {
+	UUID  string `db:"uuid,pk"`
+	Title string `db:"title"`
+}
+
+func TestSaveClientKey(t *testing.T) {
+	_, err := testDB.DB.SQL.Exec(`CREATE TABLE IF NOT EXISTS docs (
+		uuid VARCHAR(36) PRIMARY KEY,
+		title VARCHAR(255)
+	)`)
+	if err != nil {
+		t.Fatalf("failed to create docs table: %v", err)
+	}
+	if _, err := testDB.DB.SQL.Exec("DELETE FROM docs"); err != nil {
+		t.Fatalf("failed to clear docs table: %v", err)
+	}
+
+	doc := Doc{UUID: "4f1c2b3a-0000-4000-8000-000000000001", Title: "draft"}
+	if err := testDB.Save(&doc); err != nil {
+		t.Fatalf("Save() insert failed: %v", err)
+	}
+	// Saving unchanged values must not try to insert the row again
+	if err := testDB.Save(&doc); err != nil {
+		t.Fatalf("Save() of an unchanged row failed: %v", err)
+	}
+	doc.Title = "final"
+	if err := testDB.Save(&doc); err != nil {
+		t.Fatalf("Save() update failed: %v", err)
+	}
+
+	var found Doc
+	if err := testDB.FindByKey(&found, doc.UUID); err != nil {
+		t.Fatalf("FindByKey() failed: %v", err)
+	}
+	if found.Title != "final" {
+		t.Errorf("expected title final after Save, got %q", found.Title)
+	}
+}
+
+type Membership struct {
+	UserID int    `db:"user_id,pk"`
+	TeamID int    `db:"team_id,pk"`
+	Role   string `db:"role"`
+}
+
+func TestCompositePrimaryKey(t *testing.T) {
+	_, err := testDB.DB.SQL.Exec(`CREATE TABLE IF NOT EXISTS memberships (
+		user_id INT,
+		team_id INT,
+		role VARCHAR(32),
+		PRIMARY KEY (user_id, team_id)
+	)`)
+	if err != nil {
+		t.Fatalf("failed to create memberships table: %v", err)
+	}
+	if _, err := testDB.DB.SQL.Exec("DELETE FROM memberships"); err != nil {
+		t.Fatalf("failed to clear memberships table: %v", err)
+	}
+
+	m := Membership{UserID: 1, TeamID: 2, Role: "member"}
+	if err := testDB.Save(&m); err != nil {
+		t.Fatalf("Save() insert failed: %v", err)
+	}
+	m.Role = "owner"
+	if err := testDB.Save(&m); err != nil {
+		t.Fatalf("Save() update failed: %v", err)
+	}
+	if err := testDB.Save(&Membership{UserID: 1, TeamID: 3, Role: "member"}); err != nil {
+		t.Fatalf("Save() second insert failed: %v", err)
+	}
+
+	var found Membership
+	if err := testDB.FindByKey(&found, map[string]any{"UserID": 1, "team_id": 2}); err != nil {
+		t.Fatalf("FindByKey() failed: %v", err)
+	}
+	if found.Role != "owner" {
+		t.Errorf("expected role owner after Save, got %q", found.Role)
+	}
+
+	var list []Membership
+	keys := []Membership{{UserID: 1, TeamID: 2}, {UserID: 1, TeamID: 3}, {UserID: 9, TeamID: 9}}
+	if err := testDB.FindByKeys(&list, keys); err != nil {
+		t.Fatalf("FindByKeys() failed: %v", err)
+	}
+	if len(list) != 2 {
+		t.Errorf("expected 2 memberships, got %d", len(list))
+	}
+
+	if err := testDB.FindByID(&found, 1); !errors.Is(err, torm.ErrMissingPrimaryKey) {
+		t.Errorf("expected ErrMissingPrimaryKey from FindByID, got: %v", err)
+	}
+
+	if err := testDB.DeleteModel(&m); err != nil {
+		t.Fatalf("DeleteModel() failed: %v", err)
+	}
+	if err := testDB.FindByKey(&found, m); err != torm.ErrNoRows {
+		t.Errorf("expected ErrNoRows after DeleteModel, got: %v", err)
+	}
+}
+
+type Article struct {
+	ID        int    `db:"id"`
+	Title     string `db:"title"`
+	CreatedAt int64  `db:"created_at"`
+	UpdatedAt int64  `db:"updated_at"`
+}
+
+func TestTimestamps(t *testing.T) {
+	_, err := testDB.DB.SQL.Exec(`CREATE TABLE IF NOT EXISTS articles (
+		id INT PRIMARY KEY AUTO_INCREMENT,
+		title VARCHAR(255),
+		created_at BIGINT,
+		updated_at BIGINT
+	)`)
+	if err != nil {
+		t.Fatalf("failed to create articles table: %v", err)
+	}
+	if _, err := testDB.DB.SQL.Exec("DELETE FROM articles"); err != nil {
+		t.Fatalf("failed to clear articles table: %v", err)
+	}
+
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	db := testDB.WithNowFunc(func() time.Time { return now })
+
+	articles := []Article{{Title: "one"}, {Title: "two"}}
+	if err := db.Create(&Article{}, articles); err != nil {
+		t.Fatalf("Create() failed: %v", err)
+	}
+	if articles[1].CreatedAt != now.Unix() || articles[1].UpdatedAt != now.Unix() {
+		t.Errorf("expected timestamps %d on insert, got %+v", now.Unix(), articles[1])
+	}
+
+	now = now.Add(time.Hour)
+	if err := db.Update(&Article{}, map[string]any{"title": "uno"}, "WHERE title = ?", "one"); err != nil {
+		t.Fatalf("Update() failed: %v", err)
+	}
+
+	var a Article
+	if err := db.Model(&Article{}).Where("title = ?", "uno").First(&a); err != nil {
+		t.Fatalf("First() failed: %v", err)
+	}
+	if a.UpdatedAt != now.Unix() || a.CreatedAt != now.Add(-time.Hour).Unix() {
+		t.Errorf("expected only updated_at to move, got %+v", a)
+	}
+}
+
+type Member struct {
+	ID        int        `db:"id"`
+	Name      string     `db:"name"`
+	DeletedAt *time.Time `db:"deleted_at"`
+}
+
+func TestSoftDelete(t *testing.T) {
+	_, err := testDB.DB.SQL.Exec(`CREATE TABLE IF NOT EXISTS members (
+		id INT PRIMARY KEY AUTO_INCREMENT,
+		name VARCHAR(255),
+		deleted_at DATETIME NULL
+	)`)
+	if err != nil {
+		t.Fatalf("failed to create members table: %v", err)
+	}
+	if _, err := testDB.DB.SQL.Exec("DELETE FROM members"); err != nil {
+		t.Fatalf("failed to clear members table: %v", err)
+	}
+
+	members := []Member{{Name: "Buffon"}, {Name: "Chiellini"}, {Name: "Bonucci"}}
+	if err := testDB.Create(&Member{}, members); err != nil {
+		t.Fatalf("Create() failed: %v", err)
+	}
+
+	if err := testDB.Delete(&Member{}, "WHERE name = ?", "Buffon"); err != nil {
+		t.Fatalf("Delete() failed: %v", err)
+	}
+	if _, err := testDB.Model(&Member{}).Where("name = ?", "Chiellini").Delete(); err != nil {
+		t.Fatalf("Builder.Delete() failed: %v", err)
+	}
+
+	var live []Member
+	if err := testDB.Find(&Member{}, &live); err != nil {
+		t.Fatalf("Find() failed: %v", err)
+	}
+	if len(live) != 1 || live[0].Name != "Bonucci" {
+		t.Errorf("expected only Bonucci to be visible, got %+v", live)
+	}
+	if err := testDB.FindByID(&Member{}, members[0].ID); err != torm.ErrNoRows {
+		t.Errorf("expected ErrNoRows for soft-deleted row, got: %v", err)
+	}
+
+	total, err := testDB.Model(&Member{}).Unscoped().Count()
+	if err != nil || total != 3 {
+		t.Errorf("Unscoped().Count() = %d, %v; want 3", total, err)
+	}
+
+	if err := testDB.Restore(&Member{}, "WHERE name = ?", "Buffon"); err != nil {
+		t.Fatalf("Restore() failed: %v", err)
+	}
+	if count, _ := testDB.Model(&Member{}).Count(); count != 2 {
+		t.Errorf("expected 2 live members after Restore, got %d", count)
+	}
+
+	if err := testDB.Unscoped().Delete(&Member{}, "WHERE name = ?", "Chiellini"); err != nil {
+		t.Fatalf("Unscoped().Delete() failed: %v", err)
+	}
+	if total, _ := testDB.Model(&Member{}).Unscoped().Count(); total != 2 {
+		t.Errorf("expected hard delete to remove the row, %d rows left", total)
+	}
+
+	bonucci := members[2]
+	if err := testDB.DeleteModel(&bonucci); err != nil {
+		t.Fatalf("DeleteModel() failed: %v", err)
+	}
+	if bonucci.DeletedAt == nil {
+		t.Error("expected DeleteModel to set DeletedAt on the object")
+	}
+	bonucci.Name = "Leo"
+	if err := testDB.Save(&bonucci); err != nil {
+		t.Fatalf("Save() failed: %v", err)
+	}
+	if err := testDB.FindByID(&Member{}, bonucci.ID); err != torm.ErrNoRows {
+		t.Errorf("expected Save not to bring back a deleted row, got: %v", err)
+	}
+
+	if err := testDB.Restore(&User{}, "WHERE id = ?", 1); !errors.Is(err, torm.ErrNoSoftDelete) {
+		t.Errorf("expected ErrNoSoftDelete, got: %v", err)
+	}
+}
+
+type Ticket struct {
+	ID      int    `db:"id"`
+	Title   string `db:"title"`
+	Version int    `db:"version,version"`
+}
+
+func TestOptimisticLocking(t *testing.T) {
+	_, err := testDB.DB.SQL.Exec(`CREATE TABLE IF NOT EXISTS tickets (
+		id INT PRIMARY KEY AUTO_INCREMENT,
+		title VARCHAR(255),
+		version INT NOT NULL
+	)`)
+	if err != nil {
+		t.Fatalf("failed to create tickets table: %v", err)
+	}
+	if _, err := testDB.DB.SQL.Exec("DELETE FROM tickets"); err != nil {
+		t.Fatalf("failed to clear tickets table: %v", err)
+	}
+
+	ticket := Ticket{Title: "draft"}
+	if err := testDB.Save(&ticket); err != nil {
+		t.Fatalf("Save() insert failed: %v", err)
+	}
+	if ticket.Version != 1 {
+		t.Errorf("expected version 1 after insert, got %d", ticket.Version)
+	}
+
+	// Two admins load the same ticket
+	var first, second Ticket
+	if err := testDB.FindByID(&first, ticket.ID); err != nil {
+		t.Fatalf("FindByID() failed: %v", err)
+	}
+	second = first
+
+	first.Title = "first"
+	if err := testDB.Save(&first); err != nil {
+		t.Fatalf("Save() update failed: %v", err)
+	}
+	if first.Version != 2 {
+		t.Errorf("expected version 2 after update, got %d", first.Version)
+	}
+
+	second.Title = "second"
+	if err := testDB.Save(&second); !errors.Is(err, torm.ErrStaleObject) {
+		t.Errorf("expected ErrStaleObject for stale Save, got: %v", err)
+	}
+
+	err = testDB.Update(&Ticket{}, map[string]any{"title": "third", "version": 1}, "WHERE id = ?", ticket.ID)
+	if !errors.Is(err, torm.ErrStaleObject) {
+		t.Errorf("expected ErrStaleObject for stale Update, got: %v", err)
+	}
+	err = testDB.Update(&Ticket{}, map[string]any{"title": "third", "version": 2}, "WHERE id = ?", ticket.ID)
+	if err != nil {
+		t.Fatalf("Update() with current version failed: %v", err)
+	}
+
+	if err := testDB.Reload(&ticket); err != nil {
+		t.Fatalf("Reload() failed: %v", err)
+	}
+	if ticket.Title != "third" || ticket.Version != 3 {
+		t.Errorf("expected title third at version 3, got %+v", ticket)
+	}
+}
+
+type Seat struct {
+	Match   int    `db:"match_id,pk"`
+	Number  int    `db:"number,pk"`
+	Holder  string `db:"holder"`
+	Version int    `db:"version,version"`
+}
+
+func TestOptimisticLockingCompositeKey(t *testing.T) {
+	_, err := testDB.DB.SQL.Exec(`CREATE TABLE IF NOT EXISTS seats (
+		match_id INT,
+		number INT,
+		holder VARCHAR(255),
+		version INT NOT NULL,
+		PRIMARY KEY (match_id, number)
+	)`)
+	if err != nil {
+		t.Fatalf("failed to create seats table: %v", err)
+	}
+	if _, err := testDB.DB.SQL.Exec("DELETE FROM seats"); err != nil {
+		t.Fatalf("failed to clear seats table: %v", err)
+	}
+
+	seat := Seat{Match: 1, Number: 7, Holder: "Buffon"}
+	if err := testDB.Save(&seat); err != nil {
+		t.Fatalf("Save() insert failed: %v", err)
+	}
+	if seat.Version != 1 {
+		t.Errorf("expected version 1 after insert, got %d", seat.Version)
+	}
+
+	stale := seat
+	seat.Holder = "Peruzzi"
+	if err := testDB.Save(&seat); err != nil {
+		t.Fatalf("Save() update failed: %v", err)
+	}
+	if seat.Version != 2 {
+		t.Errorf("expected version 2 after update, got %d", seat.Version)
+	}
+
+	stale.Holder = "Toldo"
+	if err := testDB.Save(&stale); !errors.Is(err, torm.ErrStaleObject) {
+		t.Errorf("expected ErrStaleObject for stale Save, got: %v", err)
+	}
+
+	var found Seat
+	if err := testDB.FindByKey(&found, seat); err != nil {
+		t.Fatalf("FindByKey() failed: %v", err)
+	}
+	if found.Holder != "Peruzzi" || found.Version != 2 {
+		t.Errorf("expected holder Peruzzi at version 2, got %+v", found)
+	}
+}
+
+func TestResultAndRequireAffected(t *testing.T) {
+	setupTable(t)
+
+	users := []User{{Name: "Pirlo", Age: 45}, {Name: "Gattuso", Age: 46}, {Name: "Nesta", Age: 48}}
+	if err := testDB.Create(&User{}, users); err != nil {
+		t.Fatalf("Create() failed: %v", err)
+	}
+
+	res, err := testDB.Model(&User{}).Where("age > ?", 45).Delete()
+	if err != nil {
+		t.Fatalf("Builder.Delete() failed: %v", err)
+	}
+	if res.RowsAffected != 2 {
+		t.Errorf("expected 2 rows affected, got %d", res.RowsAffected)
+	}
+
+	if err := testDB.Update(&User{}, map[string]any{"age": 1}, "WHERE id = ?", -1); err != nil {
+		t.Errorf("expected no error for an update matching nothing, got: %v", err)
+	}
+
+	strict := testDB.RequireAffected()
+	if err := strict.Update(&User{}, map[string]any{"age": 1}, "WHERE id = ?", -1); err != torm.ErrNoRows {
+		t.Errorf("expected ErrNoRows from Update, got: %v", err)
+	}
+	if err := strict.Delete(&User{}, "WHERE id = ?", -1); err != torm.ErrNoRows {
+		t.Errorf("expected ErrNoRows from Delete, got: %v", err)
+	}
+	if err := strict.Save(&User{ID: -1, Name: "ghost"}); err != torm.ErrNoRows {
+		t.Errorf("expected ErrNoRows from Save, got: %v", err)
+	}
+	if err := strict.Update(&User{}, map[string]any{"age": 46}, "WHERE id = ?", users[0].ID); err != nil {
+		t.Errorf("expected strict Update of an existing row to succeed, got: %v", err)
+	}
+}
+
+func TestBuilderUpdateAndDelete(t *testing.T) {
+	setupTable(t)
+
+	users := []User{{Name: "Del Piero", Age: 30}, {Name: "Inzaghi", Age: 31}, {Name: "Vieri", Age: 32}, {Name: "Toni", Age: 33}}
+	if err := testDB.Create(&User{}, users); err != nil {
+		t.Fatalf("Create() failed: %v", err)
+	}
+
+	res, err := testDB.Model(&User{}).Where("age >= ?", 32).Update(map[string]any{"Age": 40})
+	if err != nil {
+		t.Fatalf("Builder.Update() failed: %v", err)
+	}
+	if res.RowsAffected != 2 {
+		t.Errorf("expected 2 rows updated, got %d", res.RowsAffected)
+	}
+
+	res, err = testDB.Model(&User{}).Where("age < ?", 40).Order("age DESC").Limit(1).UpdateColumn("name", "Pippo")
+	if err != nil {
+		t.Fatalf("Builder.UpdateColumn() failed: %v", err)
+	}
+	var pippo User
+	if err := testDB.FindByID(&pippo, users[1].ID); err != nil || pippo.Name != "Pippo" {
+		t.Errorf("expected only the oldest matching user renamed, got %+v, %v", pippo, err)
+	}
+
+	if _, err := testDB.Model(&User{}).Where("id = ?", users[0].ID).Update(User{Name: "Alex", Age: 29}); err != nil {
+		t.Fatalf("Builder.Update(struct) failed: %v", err)
+	}
+	var alex User
+	if err := testDB.FindByID(&alex, users[0].ID); err != nil || alex.Name != "Alex" || alex.Age != 29 {
+		t.Errorf("expected struct update to be written, got %+v, %v", alex, err)
+	}
+
+	res, err = testDB.Model(&User{}).Where("age = ?", 40).Order("id").Limit(1).Delete()
+	if err != nil {
+		t.Fatalf("Builder.Delete() failed: %v", err)
+	}
+	if res.RowsAffected != 1 {
+		t.Errorf("expected Limit(1) to delete 1 row, got %d", res.RowsAffected)
+	}
+	if count, _ := testDB.Model(&User{}).Count(); count != 3 {
+		t.Errorf("expected 3 users left, got %d", count)
+	}
+
+	// An OR stays inside its own Where: neither user matches both conditions
+	or := testDB.Model(&User{}).Where("name = ? OR age = ?", "Alex", 40).Where("id = ?", users[1].ID)
+	if res, err := or.Update(map[string]any{"Age": 50}); err != nil || res.RowsAffected != 0 {
+		t.Errorf("expected Update with an OR condition to match no rows, got %d, %v", res.RowsAffected, err)
+	}
+	if res, err := or.Delete(); err != nil || res.RowsAffected != 0 {
+		t.Errorf("expected Delete with an OR condition to match no rows, got %d, %v", res.RowsAffected, err)
+	}
+	if count, _ := testDB.Model(&User{}).Count(); count != 3 {
+		t.Errorf("expected 3 users left, got %d", count)
+	}
+
+	if _, err := testDB.Model(&User{}).Offset(1).Delete(); err == nil {
+		t.Error("expected an error for Delete with Offset")
+	}
+}
+
+func TestGlobalUpdateGuard(t *testing.T) {
+	setupTable(t)
+
+	if err := testDB.Create(&User{}, []User{{Name: "Cannavaro", Age: 50}, {Name: "Zambrotta", Age: 47}}); err != nil {
+		t.Fatalf("Create() failed: %v", err)
+	}
+
+	if err := testDB.Delete(&User{}, ""); !errors.Is(err, torm.ErrMissingWhereClause) {
+		t.Errorf("expected ErrMissingWhereClause from Delete, got: %v", err)
+	}
+	if err := testDB.Update(&User{}, map[string]any{"age": 1}, ""); !errors.Is(err, torm.ErrMissingWhereClause) {
+		t.Errorf("expected ErrMissingWhereClause from Update, got: %v", err)
+	}
+	if _, err := testDB.Model(&User{}).Limit(1).Delete(); !errors.Is(err, torm.ErrMissingWhereClause) {
+		t.Errorf("expected ErrMissingWhereClause from Builder.Delete, got: %v", err)
+	}
+	if count, _ := testDB.Model(&User{}).Count(); count != 2 {
+		t.Fatalf("expected the guard to keep both rows, got %d", count)
+	}
+
+	res, err := testDB.Model(&User{}).AllowGlobalUpdate().Update(map[string]any{"age": 40})
+	if err != nil || res.RowsAffected != 2 {
+		t.Errorf("AllowGlobalUpdate().Update() = %+v, %v; want 2 rows", res, err)
+	}
+	if err := testDB.AllowGlobalUpdate().Delete(&User{}, ""); err != nil {
+		t.Errorf("AllowGlobalUpdate().Delete() failed: %v", err)
+	}
+	if count, _ := testDB.Model(&User{}).Count(); count != 0 {
+		t.Errorf("expected the table to be empty, got %d rows", count)
+	}
+}
+
+func TestUpdateColumnValidation(t *testing.T) {
+	setupTable(t)
+
+	user := User{Name: "Materazzi", Age: 50}
+	if err := testDB.Create(&User{}, &user); err != nil {
+		t.Fatalf("Create() failed: %v", err)
+	}
+
+	err := testDB.Update(&User{}, map[string]any{"age = 0, name": "x"}, "WHERE id = ?", user.ID)
+	if !errors.Is(err, torm.ErrUnknownColumn) {
+		t.Errorf("expected ErrUnknownColumn for an injected key, got: %v", err)
+	}
+	if _, err := testDB.Model(&User{}).Where("id = ?", user.ID).UpdateColumn("nmae", "x"); !errors.Is(err, torm.ErrUnknownColumn) {
+		t.Errorf("expected ErrUnknownColumn for a typo, got: %v", err)
+	}
+
+	if err := testDB.Update(&User{}, map[string]any{"Name": "Marco", "age": 51}, "WHERE id = ?", user.ID); err != nil {
+		t.Fatalf("Update() with field and column names failed: %v", err)
+	}
+	var got User
+	if err := testDB.FindByID(&got, user.ID); err != nil || got.Name != "Marco" || got.Age != 51 {
+		t.Errorf("expected Marco, 51; got %+v, %v", got, err)
+	}
+}
+
+func TestBuilderUpdates(t *testing.T) {
+	setupTable(t)
+
+	user := User{Name: "Camoranesi", Age: 48}
+	if err := testDB.Create(&User{}, &user); err != nil {
+		t.Fatalf("Create() failed: %v", err)
+	}
+
+	if _, err := testDB.Model(&User{}).Where("id = ?", user.ID).Updates(&User{Name: "Mauro"}); err != nil {
+		t.Fatalf("Updates() failed: %v", err)
+	}
+	var got User
+	if err := testDB.FindByID(&got, user.ID); err != nil || got.Name != "Mauro" || got.Age != 48 {
+		t.Errorf("expected zero Age to be skipped, got %+v, %v", got, err)
+	}
+
+	if _, err := testDB.Model(&User{}).Where("id = ?", user.ID).Select("Age").Updates(&User{Name: "ignored"}); err != nil {
+		t.Fatalf("Select().Updates() failed: %v", err)
+	}
+	if err := testDB.FindByID(&got, user.ID); err != nil || got.Name != "Mauro" || got.Age != 0 {
+		t.Errorf("expected only the selected zero Age to be written, got %+v, %v", got, err)
+	}
+
+	if _, err := testDB.Model(&User{}).Where("id = ?", user.ID).Omit("Name").Updates(&User{Name: "ignored", Age: 49}); err != nil {
+		t.Fatalf("Omit().Updates() failed: %v", err)
+	}
+	if err := testDB.FindByID(&got, user.ID); err != nil || got.Name != "Mauro" || got.Age != 49 {
+		t.Errorf("expected the omitted Name to be kept, got %+v, %v", got, err)
+	}
+}
+
+func TestExprAndCounters(t *testing.T) {
+	_, err := testDB.DB.SQL.Exec(`CREATE TABLE IF NOT EXISTS products (
+		id INT PRIMARY KEY AUTO_INCREMENT,
+		sku VARCHAR(64) UNIQUE,
+		price INT,
+		stock INT
+	)`)
+	if err != nil {
+		t.Fatalf("failed to create products table: %v", err)
+	}
+	if _, err := testDB.DB.SQL.Exec("DELETE FROM products"); err != nil {
+		t.Fatalf("failed to clear products table: %v", err)
+	}
+
+	p := Product{SKU: "shirt", Price: 20, Stock: 10}
+	if err := testDB.Create(&Product{}, &p); err != nil {
+		t.Fatalf("Create() failed: %v", err)
+	}
+
+	if err := testDB.Update(&Product{}, map[string]any{"stock": torm.Expr("stock - ?", 3)}, "WHERE id = ?", p.ID); err != nil {
+		t.Fatalf("Update() with Expr failed: %v", err)
+	}
+	if _, err := testDB.Model(&Product{}).Where("id = ?", p.ID).Increment("Stock", 5); err != nil {
+		t.Fatalf("Increment() failed: %v", err)
+	}
+	res, err := testDB.Model(&Product{}).Where("id = ? AND stock >= ?", p.ID, 100).Decrement("stock", 100)
+	if err != nil || res.RowsAffected != 0 {
+		t.Errorf("expected a guarded Decrement to change nothing, got %+v, %v", res, err)
+	}
+
+	type restock struct {
+		Stock clause.Expr `db:"stock"`
+	}
+	if _, err := testDB.Model(&Product{}).Where("id = ?", p.ID).Updates(&restock{Stock: torm.Expr("stock + ?", 10)}); err != nil {
+		t.Fatalf("Updates() with an Expr field failed: %v", err)
+	}
+	if err := testDB.Update(&Product{}, map[string]any{"price": 25}, "WHERE stock > ?", torm.Expr("? - 10", 11)); err != nil {
+		t.Fatalf("Update() with an Expr argument failed: %v", err)
+	}
+
+	var got Product
+	if err := testDB.FindByID(&got, p.ID); err != nil || got.Stock != 22 || got.Price != 25 {
+		t.Errorf("expected stock 22 and price 25, got %+v, %v", got, err)
+	}
+
+	var cheap []Product
+	if err := testDB.Model(&Product{}).Where("price < ?", torm.Expr("stock * ?", 2)).Find(&cheap); err != nil {
+		t.Fatalf("Where() with Expr failed: %v", err)
+	}
+	if len(cheap) != 1 {
+		t.Errorf("expected price 20 < stock 12 * 2 to match, got %d rows", len(cheap))
+	}
+}
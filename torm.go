@@ -3,8 +3,12 @@ package torm
 import (
 	"context"
 	"database/sql"
+	"fmt"
+	"time"
 
+	"github.com/adipras/torm/clause"
 	"github.com/adipras/torm/executor"
+	"github.com/adipras/torm/model"
 	"github.com/adipras/torm/query"
 
 	"github.com/adipras/torm/db"
@@ -12,8 +16,42 @@ import (
 
 var ErrNoRows = sql.ErrNoRows
 
+var ErrNotInTransaction = db.ErrNotInTransaction
+
+var ErrReturningNotSupported = executor.ErrReturningNotSupported
+
+var ErrMissingPrimaryKey = executor.ErrMissingPrimaryKey
+
+var ErrNoSoftDelete = executor.ErrNoSoftDelete
+
+var ErrStaleObject = executor.ErrStaleObject
+
+var ErrUnknownColumn = executor.ErrUnknownColumn
+
+var ErrMissingWhereClause = executor.ErrMissingWhereClause
+
+// Result reports the rows affected by an update or delete.
+type Result = executor.Result
+
+// OnConflict controls how Upsert resolves unique key conflicts.
+type OnConflict = clause.OnConflict
+
+// Expr returns a raw SQL expression with bind values, accepted in Update
+// maps, Updates, Upsert Set and Where arguments, e.g.
+//
+//	db.Update(&Product{}, map[string]any{"stock": torm.Expr("stock - ?", 1)}, "WHERE id = ?", id)
+//
+// A struct field passed to Updates holds it as a clause.Expr.
+func Expr(sql string, vars ...any) clause.Expr {
+	return clause.Expr{SQL: sql, Vars: vars}
+}
+
+// NamingStrategy maps struct and field names to table and column names.
+type NamingStrategy = model.NamingStrategy
+
 type Torm struct {
-	DB *db.DB
+	DB  *db.DB
+	ctx context.Context
 }
 
 // Open opens a database connection using the given driver and DSN.
@@ -30,19 +68,182 @@ func (t *Torm) Close() error {
 	return t.DB.SQL.Close()
 }
 
+// WithContext returns a copy of t whose operations, including builders
+// created via Model, run with ctx.
+func (t *Torm) WithContext(ctx context.Context) *Torm {
+	return &Torm{DB: t.DB, ctx: ctx}
+}
+
+// WithTimeout returns a copy of t that bounds each query by d when the
+// context has no deadline of its own. A zero d disables the timeout.
+// The default is db.DefaultTimeout.
+func (t *Torm) WithTimeout(d time.Duration) *Torm {
+	conn := *t.DB
+	conn.Timeout = d
+	return &Torm{DB: &conn, ctx: t.ctx}
+}
+
+// WithNamingStrategy returns a copy of t that derives table and column
+// names with ns, e.g. &torm.NamingStrategy{TablePrefix: "app_"}.
+// A TableName method on a model and explicit db tags still take precedence.
+func (t *Torm) WithNamingStrategy(ns *NamingStrategy) *Torm {
+	conn := *t.DB
+	conn.Naming = ns
+	return &Torm{DB: &conn, ctx: t.ctx}
+}
+
+// WithNowFunc returns a copy of t that reads the time for automatic
+// CreatedAt/UpdatedAt timestamps from now, e.g. a fixed clock in tests.
+func (t *Torm) WithNowFunc(now func() time.Time) *Torm {
+	conn := *t.DB
+	conn.NowFunc = now
+	return &Torm{DB: &conn, ctx: t.ctx}
+}
+
+// Unscoped returns a copy of t that ignores soft deletes: queries include
+// soft-deleted rows and deletes remove rows permanently.
+func (t *Torm) Unscoped() *Torm {
+	conn := *t.DB
+	conn.Unscoped = true
+	return &Torm{DB: &conn, ctx: t.ctx}
+}
+
+// RequireAffected returns a copy of t whose updates and deletes return
+// ErrNoRows when they affect no rows, e.g. when the row to update by ID
+// does not exist. Save updates are covered too.
+func (t *Torm) RequireAffected() *Torm {
+	conn := *t.DB
+	conn.RequireAffected = true
+	return &Torm{DB: &conn, ctx: t.ctx}
+}
+
+// AllowGlobalUpdate returns a copy of t whose updates and deletes may run
+// without a WHERE condition and affect every row, e.g. for maintenance jobs.
+func (t *Torm) AllowGlobalUpdate() *Torm {
+	conn := *t.DB
+	conn.AllowGlobalUpdate = true
+	return &Torm{DB: &conn, ctx: t.ctx}
+}
+
+func (t *Torm) context() context.Context {
+	if t.ctx == nil {
+		return context.Background()
+	}
+	return t.ctx
+}
+
+// Begin starts a transaction and returns a Torm bound to it.
+// All operations on the returned Torm, including builders created via Model,
+// run inside the transaction until Commit or Rollback is called.
+// Calling Begin on a Torm that is already in a transaction creates a SAVEPOINT.
+func (t *Torm) Begin() (*Torm, error) {
+	return t.begin(t.context())
+}
+
+func (t *Torm) begin(ctx context.Context) (*Torm, error) {
+	tx, err := t.DB.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &Torm{DB: tx, ctx: ctx}, nil
+}
+
+// Commit commits the transaction started by Begin.
+// For a nested transaction it releases the savepoint.
+func (t *Torm) Commit() error {
+	return t.DB.Commit()
+}
+
+// Rollback aborts the transaction started by Begin.
+// For a nested transaction it rolls back to the savepoint.
+func (t *Torm) Rollback() error {
+	return t.DB.Rollback()
+}
+
+// Transaction runs fn inside a transaction.
+// The transaction is committed if fn returns nil and rolled back if fn
+// returns an error or panics (the panic is re-raised after the rollback).
+// Nested calls on the tx passed to fn are mapped to SAVEPOINTs.
+func (t *Torm) Transaction(ctx context.Context, fn func(tx *Torm) error) (err error) {
+	tx, err := t.begin(ctx)
+	if err != nil {
+		return err
+	}
+
+	panicked := true
+	defer func() {
+		if panicked {
+			_ = tx.Rollback()
+		}
+	}()
+
+	if err = fn(tx); err != nil {
+		panicked = false
+		if rbErr := tx.Rollback(); rbErr != nil {
+			return fmt.Errorf("%w (rollback failed: %v)", err, rbErr)
+		}
+		return err
+	}
+
+	panicked = false
+	return tx.Commit()
+}
+
 // Create inserts one or more rows
 // into the database based on the provided schema and data.
 // It takes a schema reference (struct type) and the data to insert.
 // The data can be a single struct or a slice of structs.
 func (t *Torm) Create(schema any, data any) error {
-	return executor.Create(t.DB.SQL, schema, data)
+	return t.CreateContext(t.context(), schema, data)
+}
+
+// CreateContext is like Create but runs the query with the provided context.
+func (t *Torm) CreateContext(ctx context.Context, schema any, data any) error {
+	return executor.CreateContext(t.DB, ctx, schema, data)
+}
+
+// CreateInBatches inserts a slice of structs using multi-row INSERTs
+// of at most batchSize rows each. A batchSize <= 0 uses the largest batch
+// the dialect's placeholder limit allows, which is also what Create does.
+func (t *Torm) CreateInBatches(schema any, data any, batchSize int) error {
+	return t.CreateInBatchesContext(t.context(), schema, data, batchSize)
+}
+
+// CreateInBatchesContext is like CreateInBatches but runs the queries with the provided context.
+func (t *Torm) CreateInBatchesContext(ctx context.Context, schema any, data any, batchSize int) error {
+	return executor.CreateInBatchesContext(t.DB, ctx, schema, data, batchSize)
+}
+
+// Upsert inserts data, a struct or a slice of structs, and resolves
+// unique key conflicts as described by conflict:
+//
+//	// Insert or overwrite every column except the conflict target
+//	db.Upsert(&Product{}, &products, torm.OnConflict{Columns: []string{"sku"}, UpdateAll: true})
+//
+//	// Insert, or only refresh the price of existing rows
+//	db.Upsert(&Product{}, &p, torm.OnConflict{Columns: []string{"sku"}, DoUpdates: []string{"price"}})
+//
+//	// Insert, or keep existing rows untouched
+//	db.Upsert(&Product{}, &p, torm.OnConflict{DoNothing: true})
+func (t *Torm) Upsert(schema any, data any, conflict OnConflict) error {
+	return t.UpsertContext(t.context(), schema, data, conflict)
+}
+
+// UpsertContext is like Upsert but runs the query with the provided context.
+func (t *Torm) UpsertContext(ctx context.Context, schema any, data any, conflict OnConflict) error {
+	return executor.UpsertContext(t.DB, ctx, schema, data, conflict)
 }
 
 // Find retrieves rows from the database based on the provided schema.
 // It takes a schema reference (struct type) and a destination variable
 // where the results will be stored.
 func (t *Torm) Find(schema any, dest any) error {
-	return executor.Find(t.DB.SQL, schema, dest)
+	return t.FindContext(t.context(), schema, dest)
+}
+
+// FindContext is like Find but runs the query with the provided context.
+func (t *Torm) FindContext(ctx context.Context, schema any, dest any) error {
+	return executor.FindContext(t.DB, ctx, schema, dest)
 }
 
 // First finds the first matching row based on condition and maps it to dest.
@@ -50,33 +251,160 @@ func (t *Torm) Find(schema any, dest any) error {
 // If no rows match, it returns sql.ErrNoRows.
 // If multiple rows match, it only returns the first one.
 func (t *Torm) First(schema any, dest any, whereClause string, args ...any) error {
-	return executor.First(t.DB.SQL, schema, dest, whereClause, args...)
+	return t.FirstContext(t.context(), schema, dest, whereClause, args...)
+}
+
+// FirstContext is like First but runs the query with the provided context.
+func (t *Torm) FirstContext(ctx context.Context, schema any, dest any, whereClause string, args ...any) error {
+	return executor.FirstContext(t.DB, ctx, schema, dest, whereClause, args...)
+}
+
+// FindByID retrieves the row whose primary key equals id into dest.
+// The primary key is the field tagged `db:"...,pk"`, or the ID field.
+// If no row matches, it returns sql.ErrNoRows.
+func (t *Torm) FindByID(dest any, id any) error {
+	return t.FindByIDContext(t.context(), dest, id)
+}
+
+// FindByIDContext is like FindByID but runs the query with the provided context.
+func (t *Torm) FindByIDContext(ctx context.Context, dest any, id any) error {
+	return executor.FindByIDContext(t.DB, ctx, dest, id)
+}
+
+// FindByKey retrieves the row identified by key into dest.
+// For composite primary keys, key is a map keyed by field or column name,
+// or a struct with the key fields set, e.g. map[string]any{"UserID": 1, "RoleID": 2}.
+// If no row matches, it returns sql.ErrNoRows.
+func (t *Torm) FindByKey(dest any, key any) error {
+	return t.FindByKeyContext(t.context(), dest, key)
+}
+
+// FindByKeyContext is like FindByKey but runs the query with the provided context.
+func (t *Torm) FindByKeyContext(ctx context.Context, dest any, key any) error {
+	return executor.FindByKeyContext(t.DB, ctx, dest, key)
+}
+
+// FindByKeys retrieves every row identified by keys, a slice of keys as
+// accepted by FindByKey, into dest, a pointer to slice.
+func (t *Torm) FindByKeys(dest any, keys any) error {
+	return t.FindByKeysContext(t.context(), dest, keys)
+}
+
+// FindByKeysContext is like FindByKeys but runs the query with the provided context.
+func (t *Torm) FindByKeysContext(ctx context.Context, dest any, keys any) error {
+	return executor.FindByKeysContext(t.DB, ctx, dest, keys)
+}
+
+// Save inserts obj when its primary key is zero and updates the row
+// with that primary key otherwise. Models with a composite primary key
+// are upserted on the key columns. obj must be a pointer to struct.
+// For models with a `db:"...,version"` field, the update only applies if the
+// row still has obj's version and returns ErrStaleObject otherwise.
+func (t *Torm) Save(obj any) error {
+	return t.SaveContext(t.context(), obj)
+}
+
+// SaveContext is like Save but runs the query with the provided context.
+func (t *Torm) SaveContext(ctx context.Context, obj any) error {
+	return executor.SaveContext(t.DB, ctx, obj)
+}
+
+// DeleteModel deletes the row identified by obj's primary key.
+func (t *Torm) DeleteModel(obj any) error {
+	return t.DeleteModelContext(t.context(), obj)
+}
+
+// DeleteModelContext is like DeleteModel but runs the query with the provided context.
+func (t *Torm) DeleteModelContext(ctx context.Context, obj any) error {
+	return executor.DeleteModelContext(t.DB, ctx, obj)
+}
+
+// Reload refreshes obj from the row identified by its primary key.
+// If the row no longer exists, it returns sql.ErrNoRows.
+func (t *Torm) Reload(obj any) error {
+	return t.ReloadContext(t.context(), obj)
+}
+
+// ReloadContext is like Reload but runs the query with the provided context.
+func (t *Torm) ReloadContext(ctx context.Context, obj any) error {
+	return executor.ReloadContext(t.DB, ctx, obj)
 }
 
 // Update updates fields in a table based on a WHERE clause.
 // It takes a schema reference, a map of data to update, and a WHERE clause with optional arguments.
 func (t *Torm) Update(schema any, data map[string]any, whereClause string, args ...any) error {
-	return executor.Update(t.DB.SQL, schema, data, whereClause, args...)
+	return t.UpdateContext(t.context(), schema, data, whereClause, args...)
+}
+
+// UpdateContext is like Update but runs the query with the provided context.
+func (t *Torm) UpdateContext(ctx context.Context, schema any, data map[string]any, whereClause string, args ...any) error {
+	_, err := executor.UpdateContext(t.DB, ctx, schema, data, whereClause, args...)
+	return err
+}
+
+// UpdateReturning updates rows like Update and scans the updated rows into dest,
+// which must be a pointer to a slice. It requires a dialect with RETURNING
+// support and returns ErrReturningNotSupported otherwise.
+func (t *Torm) UpdateReturning(schema any, dest any, data map[string]any, whereClause string, args ...any) error {
+	return t.UpdateReturningContext(t.context(), schema, dest, data, whereClause, args...)
+}
+
+// UpdateReturningContext is like UpdateReturning but runs the query with the provided context.
+func (t *Torm) UpdateReturningContext(ctx context.Context, schema any, dest any, data map[string]any, whereClause string, args ...any) error {
+	return executor.UpdateReturningContext(t.DB, ctx, schema, dest, data, whereClause, args...)
 }
 
 // Delete removes rows from the database based on the provided schema and WHERE clause.
 // It takes a schema reference and a WHERE clause with optional arguments.
+// Models with a DeletedAt field are soft-deleted by setting it; use
+// Unscoped to remove the rows permanently.
 func (t *Torm) Delete(schema any, whereClause string, args ...any) error {
-	return executor.Delete(t.DB.SQL, schema, whereClause, args...)
+	return t.DeleteContext(t.context(), schema, whereClause, args...)
+}
+
+// DeleteContext is like Delete but runs the query with the provided context.
+func (t *Torm) DeleteContext(ctx context.Context, schema any, whereClause string, args ...any) error {
+	_, err := executor.DeleteContext(t.DB, ctx, schema, whereClause, args...)
+	return err
+}
+
+// Restore undeletes soft-deleted rows matching the WHERE clause.
+// It returns ErrNoSoftDelete if the model has no DeletedAt field.
+func (t *Torm) Restore(schema any, whereClause string, args ...any) error {
+	return t.RestoreContext(t.context(), schema, whereClause, args...)
+}
+
+// RestoreContext is like Restore but runs the query with the provided context.
+func (t *Torm) RestoreContext(ctx context.Context, schema any, whereClause string, args ...any) error {
+	_, err := executor.RestoreContext(t.DB, ctx, schema, whereClause, args...)
+	return err
+}
+
+// DeleteReturning removes rows like Delete and scans the deleted rows into dest,
+// which must be a pointer to a slice. It requires a dialect with RETURNING
+// support and returns ErrReturningNotSupported otherwise.
+func (t *Torm) DeleteReturning(schema any, dest any, whereClause string, args ...any) error {
+	return t.DeleteReturningContext(t.context(), schema, dest, whereClause, args...)
+}
+
+// DeleteReturningContext is like DeleteReturning but runs the query with the provided context.
+func (t *Torm) DeleteReturningContext(ctx context.Context, schema any, dest any, whereClause string, args ...any) error {
+	return executor.DeleteReturningContext(t.DB, ctx, schema, dest, whereClause, args...)
 }
 
 // RawSQL executes a raw SQL query with default context
+// (or the one set via WithContext)
 func (t *Torm) RawSQL(query string, args ...any) (*sql.Rows, error) {
-	return executor.RawSQL(t.DB.SQL, query, args...)
+	return executor.RawSQLContext(t.DB, t.context(), query, args...)
 }
 
 // RawSQLContext executes a raw SQL query with the provided context
 func (t *Torm) RawSQLContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
-	return executor.RawSQLContext(t.DB.SQL, ctx, query, args...)
+	return executor.RawSQLContext(t.DB, ctx, query, args...)
 }
 
 // Model initializes a query builder for the given model struct.
 // It returns a new query.Builder instance that can be used to build and execute queries.
 func (t *Torm) Model(model any) *query.Builder {
-	return query.NewBuilder(t.DB, model)
+	return query.NewBuilder(t.DB, model).WithContext(t.ctx)
 }
@@ -5,32 +5,52 @@ import (
 	"errors"
 	"fmt"
 	"reflect"
+
+	"github.com/adipras/torm/model"
 )
 
-// ToSnakeCase converts CamelCase or PascalCase to snake_case
-// Reuse from model package if shared
+// ToSnakeCase converts CamelCase or PascalCase to snake_case.
+// Acronyms are kept together: UserID -> user_id, HTTPServer -> http_server.
 func ToSnakeCase(s string) string {
-	var result []rune
-	for i, r := range s {
-		if i > 0 && r >= 'A' && r <= 'Z' {
-			result = append(result, '_')
-		}
-		result = append(result, r)
-	}
-	return stringLower(result)
+	return model.ToSnakeCase(s)
 }
 
-func stringLower(rs []rune) string {
-	for i := range rs {
-		if rs[i] >= 'A' && rs[i] <= 'Z' {
-			rs[i] += 'a' - 'A'
-		}
+// ScanFirst maps the first row from DB to dest, a pointer to struct.
+// It returns sql.ErrNoRows if there are no rows.
+func ScanFirst(rows *sql.Rows, dest any) error {
+	return ScanFirstWith(rows, dest, nil)
+}
+
+// ScanFirstWith is like ScanFirst but maps untagged fields to columns with naming.
+func ScanFirstWith(rows *sql.Rows, dest any, naming *model.NamingStrategy) error {
+	destVal := reflect.ValueOf(dest)
+	if destVal.Kind() != reflect.Ptr || destVal.Elem().Kind() != reflect.Struct {
+		return errors.New("dest must be a pointer to struct")
+	}
+
+	// Scan ke slice sementara, ambil index 0
+	tmp := reflect.New(reflect.SliceOf(destVal.Elem().Type()))
+	if err := ScanRowsWith(rows, tmp.Interface(), naming); err != nil {
+		return err
 	}
-	return string(rs)
+
+	if tmp.Elem().Len() == 0 {
+		return sql.ErrNoRows
+	}
+
+	destVal.Elem().Set(tmp.Elem().Index(0))
+	return nil
 }
 
-// ScanRows maps rows from DB to a slice of structs
+// ScanRows maps rows from DB to a slice of structs.
+// Columns are matched by name; nested and embedded struct fields can be
+// filled from prefixed columns such as "order__total" or "order.total".
 func ScanRows(rows *sql.Rows, dest any) error {
+	return ScanRowsWith(rows, dest, nil)
+}
+
+// ScanRowsWith is like ScanRows but maps untagged fields to columns with naming.
+func ScanRowsWith(rows *sql.Rows, dest any, naming *model.NamingStrategy) error {
 	destVal := reflect.ValueOf(dest)
 	if destVal.Kind() != reflect.Ptr || destVal.Elem().Kind() != reflect.Slice {
 		return errors.New("dest must be a pointer to slice")
@@ -44,40 +64,39 @@ func ScanRows(rows *sql.Rows, dest any) error {
 		return fmt.Errorf("failed to get columns: %w", err)
 	}
 
+	// Map column name -> struct field
+	fields := model.ParseWith(reflect.New(elemType).Interface(), naming).ScanFields()
+
 	for rows.Next() {
 		elemPtr := reflect.New(elemType) // *T
 		elem := elemPtr.Elem()           // T
 
 		fieldPtrs := make([]any, len(columns))
-		colToField := map[string]reflect.Value{}
-
-		// Map column name -> struct field pointer
-		for i := 0; i < elem.NumField(); i++ {
-			field := elem.Type().Field(i)
-			if !field.IsExported() {
-				continue
-			}
-
-			col := field.Tag.Get("db")
-			if col == "" {
-				col = ToSnakeCase(field.Name)
-			}
-
-			colToField[col] = elem.Field(i)
-		}
-
+		holders := make([]reflect.Value, len(columns))
 		for i, colName := range columns {
-			if f, ok := colToField[colName]; ok && f.CanAddr() {
-				fieldPtrs[i] = f.Addr().Interface()
-			} else {
+			f, ok := fields[colName]
+			switch {
+			case !ok:
 				var dummy any
 				fieldPtrs[i] = &dummy // ignore column
+			case f.Nested:
+				// A LEFT JOIN without a match yields NULLs: scan through a
+				// pointer so the nested struct stays nil or zero
+				holders[i] = reflect.New(reflect.PointerTo(f.GoType))
+				fieldPtrs[i] = holders[i].Interface()
+			default:
+				fieldPtrs[i] = f.Target(elem).Addr().Interface()
 			}
 		}
 
 		if err := rows.Scan(fieldPtrs...); err != nil {
 			return fmt.Errorf("failed to scan row: %w", err)
 		}
+		for i, h := range holders {
+			if h.IsValid() && !h.Elem().IsNil() {
+				fields[columns[i]].Target(elem).Set(h.Elem().Elem())
+			}
+		}
 
 		sliceVal.Set(reflect.Append(sliceVal, elem))
 	}